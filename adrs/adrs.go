@@ -0,0 +1,307 @@
+// Package adrs embeds an ADR log in other Go programs: it creates, queries and
+// updates records the way the adr command does, without shelling out.
+//
+// A Log is opened from a project directory (Open) or from any Storage with an
+// explicit configuration (New); neither looks at the working directory. Its
+// methods are safe for concurrent use.
+package adrs
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"sync"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/teris-io/shortid"
+)
+
+type (
+	// Record is an ADR: its front-matter and its body.
+	Record = records.AdrData
+	// Status is the status of a record.
+	Status = records.AdrStatus
+	// Config is the content of a configuration file (.adrrc.yml).
+	Config = cs.Config
+	// Storage holds the files of a project, with names relative to the
+	// directory of its configuration file.
+	Storage = records.Storage
+	// Diagnostic is a record file left out of the log because it could not be
+	// parsed.
+	Diagnostic = records.Diagnostic
+)
+
+// The built-in statuses. A project can declare more in its configuration.
+const (
+	Proposed   = records.PROPOSED
+	Accepted   = records.ACCEPTED
+	Deprecated = records.DEPRECATED
+	Superseded = records.SUPERSEDED
+	Observed   = records.OBSERVED
+)
+
+// The errors returned by a Log match these with errors.Is; errors.As gives
+// their details (e.g. a *records.ConflictError names the file).
+var (
+	ErrNotFound   = records.ErrNotFound
+	ErrConflict   = records.ErrConflict
+	ErrTransition = records.ErrTransition
+	ErrArchived   = records.ErrArchived
+	ErrReadOnly   = records.ErrReadOnly
+)
+
+// NewOSStorage returns the Storage of a project directory on the local file
+// system.
+func NewOSStorage(dir string) Storage {
+	return records.NewOSStorage(dir)
+}
+
+// NewFSStorage returns a read-only Storage of the files of fsys (e.g. an
+// embed.FS), rooted at the project directory.
+func NewFSStorage(fsys fs.FS) Storage {
+	return records.NewFSStorage(fsys)
+}
+
+// NewMemStorage returns an empty Storage held in memory, for tests.
+func NewMemStorage() Storage {
+	return records.NewMemStorage()
+}
+
+// CopyToMemory returns a Storage held in memory with a copy of the files of
+// fsys, for dry runs: changes never reach fsys.
+func CopyToMemory(fsys fs.FS) (Storage, error) {
+	return records.CopyToMemory(fsys)
+}
+
+// Option configures Open and New.
+type Option func(*options)
+
+type options struct {
+	collection   string
+	noCache      bool
+	metadataOnly bool
+	actor        string
+}
+
+// WithCollection selects the collection to work on, among the ones declared
+// under "collections" in the configuration.
+func WithCollection(name string) Option {
+	return func(o *options) {
+		o.collection = name
+	}
+}
+
+// WithoutCache indexes every record file, without reading or updating the index
+// cache (.adr/cache).
+func WithoutCache() Option {
+	return func(o *options) {
+		o.noCache = true
+	}
+}
+
+// WithMetadataOnly indexes the front-matter of the records only: their bodies
+// are read on demand by Body.
+func WithMetadataOnly() Option {
+	return func(o *options) {
+		o.metadataOnly = true
+	}
+}
+
+// WithActor names who makes the changes, in the status history of the records.
+func WithActor(actor string) Option {
+	return func(o *options) {
+		o.actor = actor
+	}
+}
+
+// Log is an ADR log: the records of a project, or of one of its collections.
+type Log struct {
+	mu      sync.RWMutex
+	service *records.Service
+	opts    []Option
+	storage Storage
+	config  Config
+}
+
+// Open opens the ADR log of the project whose configuration file is in root.
+func Open(ctx context.Context, root string, opts ...Option) (*Log, error) {
+	storage := NewOSStorage(root)
+	config, err := records.ReadConfig(storage)
+	if err != nil {
+		return nil, err
+	}
+	return New(ctx, storage, config, opts...)
+}
+
+// New opens the ADR log of a project held by storage and configured by config.
+func New(ctx context.Context, storage Storage, config Config, opts ...Option) (*Log, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	recordsOpts := []records.Option{records.WithContext(ctx), records.WithCollection(o.collection)}
+	if o.noCache {
+		recordsOpts = append(recordsOpts, records.WithoutCache())
+	}
+	if o.metadataOnly {
+		recordsOpts = append(recordsOpts, records.WithMetadataOnly())
+	}
+	service, err := records.Open(storage, config, recordsOpts...)
+	if err != nil {
+		return nil, err
+	}
+	service.SetActor(o.actor)
+	return &Log{service: service, opts: opts, storage: storage, config: config}, nil
+}
+
+// Collection returns the name of the log's collection ("" for projects without
+// collections).
+func (l *Log) Collection() string {
+	return l.service.Collection()
+}
+
+// Collections returns the names of the collections declared by the project.
+func (l *Log) Collections() []string {
+	return l.service.Collections()
+}
+
+// OpenCollection opens another collection of the project, with the same options.
+func (l *Log) OpenCollection(ctx context.Context, name string) (*Log, error) {
+	return New(ctx, l.storage, l.config, append(l.opts[:len(l.opts):len(l.opts)], WithCollection(name))...)
+}
+
+// Diagnostics returns the record files left out of the log because they could
+// not be parsed.
+func (l *Log) Diagnostics() []Diagnostic {
+	return l.service.Diagnostics()
+}
+
+// Records returns the records of the log, numbered records first.
+func (l *Log) Records(ctx context.Context) ([]Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.service.GetRecords(), nil
+}
+
+// Get returns the record with an ID, or an error matching ErrNotFound.
+func (l *Log) Get(ctx context.Context, id string) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	record, ok := l.service.GetRecord(id)
+	if !ok {
+		return Record{}, &records.NotFoundError{Ref: id}
+	}
+	return record, nil
+}
+
+// Resolve finds the record a reference points to, as the adr command does: an
+// ID, a filename, a number ("7", "ADR-7"), an ID prefix or a title. It returns
+// an error matching ErrNotFound when nothing matches, and a
+// *records.AmbiguousError when several records do.
+func (l *Log) Resolve(ctx context.Context, ref string) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.service.Resolve(ref)
+}
+
+// Body returns the body of a record, reading it when the log was opened
+// WithMetadataOnly.
+func (l *Log) Body(ctx context.Context, record Record) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.service.Body(record)
+}
+
+// Render returns the body of a record with the references it makes to records
+// ("[[ADR-004]]", "[[<ID>]]") turned into markdown links to their files,
+// relative to the record's, titled with the current title of the records.
+func (l *Log) Render(ctx context.Context, record Record) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	body, err := l.service.Body(record)
+	if err != nil {
+		return "", err
+	}
+	return l.service.ExpandReferences(record, body), nil
+}
+
+// Backlinks returns the records whose body refers to a record ("[[ADR-004]]").
+func (l *Log) Backlinks(ctx context.Context, record Record) ([]Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.service.Backlinks(record), nil
+}
+
+// Create writes a new record and returns it, numbered and named. A record
+// without an ID is given one. The body is written verbatim below the title.
+func (l *Log) Create(ctx context.Context, title string, record Record, body string) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+	if record.ID == "" {
+		// IDs starting with '-' would be taken for flags by the adr command.
+		for record.ID == "" || strings.HasPrefix(record.ID, "-") {
+			record.ID = shortid.MustGenerate()
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.service.CreateRecord(title, record, body)
+}
+
+// Update writes a record back and returns it as written, to base further
+// updates on. It returns an error matching ErrConflict when the file changed
+// since the record was read, ErrTransition when the workflow forbids its status
+// change, and ErrArchived for an archived record.
+func (l *Log) Update(ctx context.Context, record Record) (Record, error) {
+	if err := ctx.Err(); err != nil {
+		return Record{}, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.service.UpdateRecord(record); err != nil {
+		return Record{}, err
+	}
+	updated, _ := l.service.GetRecord(record.ID)
+	return updated, nil
+}
+
+// Link links a record to a target under a relation (e.g. "amends"): the record
+// stores the link, and the target its inverse (e.g. "amended-by").
+func (l *Log) Link(ctx context.Context, record Record, relation string, target Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.service.Link(record, relation, target)
+}
+
+// Unlink removes a link between a record and a target, on both sides.
+func (l *Log) Unlink(ctx context.Context, record Record, relation string, target Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.service.Unlink(record, relation, target)
+}
@@ -0,0 +1,113 @@
+package adrs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "adrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".adrrc.yml"), []byte("directory: adrs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLogLifecycle(t *testing.T) {
+	ctx := context.Background()
+	dir := newTestProject(t)
+
+	log, err := Open(ctx, dir, WithActor("portal"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	created, err := log.Create(ctx, "Use Go", Record{Status: Proposed}, "## Context\n")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" || created.Name != "001_use_go.md" {
+		t.Errorf("created = %+v, want an ID and 001_use_go.md", created)
+	}
+
+	created.Status = Accepted
+	updated, err := log.Update(ctx, created)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if last := updated.History[len(updated.History)-1]; last.Status != Accepted || last.Actor != "portal" {
+		t.Errorf("history = %+v, want an acceptance by portal", updated.History)
+	}
+	// The returned record is current: updating it again is not a conflict.
+	updated.Tags.Append("lang")
+	if _, err := log.Update(ctx, updated); err != nil {
+		t.Errorf("second Update: %v", err)
+	}
+
+	// A fresh log reads the records back, bodies on demand.
+	other, err := New(ctx, NewOSStorage(dir), Config{Directory: "adrs"}, WithMetadataOnly(), WithoutCache())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	record, err := other.Resolve(ctx, "ADR-1")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if record.Status != Accepted || !record.Tags["lang"] {
+		t.Errorf("record = %+v, want accepted and tagged", record)
+	}
+	body, err := other.Body(ctx, record)
+	if err != nil || body == "" {
+		t.Errorf("Body = %q, %v, want the body", body, err)
+	}
+
+	// A stale snapshot is refused.
+	if _, err := log.Update(ctx, created); !errors.Is(err, ErrConflict) {
+		t.Errorf("stale Update error = %v, want ErrConflict", err)
+	}
+	if _, err := log.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLogContext(t *testing.T) {
+	dir := newTestProject(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	log, err := Open(ctx, dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	cancel()
+
+	if _, err := log.Records(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Records error = %v, want context.Canceled", err)
+	}
+	if _, err := log.Create(ctx, "Late", Record{Status: Proposed}, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create error = %v, want context.Canceled", err)
+	}
+	if _, err := Open(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("Open error = %v, want context.Canceled", err)
+	}
+}
+
+func TestLogMemStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemStorage()
+	log, err := New(ctx, storage, Config{Directory: "."})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := log.Create(ctx, "In memory", Record{Status: Proposed}, ""); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	records, err := log.Records(ctx)
+	if err != nil || len(records) != 1 || records[0].Name != "001_in_memory.md" {
+		t.Errorf("Records = %+v, %v, want the created record", records, err)
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"context"
-	"errors"
 
 	"github.com/gwleclerc/adr/records"
 	"github.com/urfave/cli/v3"
@@ -26,6 +25,14 @@ It will keep the content and only modify the metadata.`,
 				Aliases: []string{"r"},
 				Usage:   "superseders of the record",
 			},
+			&cli.StringFlag{
+				Name:  "reason",
+				Usage: "reason for the status change, recorded in the history",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "overwrite the record even if it changed on disk, and allow any status change",
+			},
 			&cli.BoolFlag{
 				Name:  "json",
 				Usage: "print the updated record as JSON",
@@ -46,17 +53,18 @@ It will keep the content and only modify the metadata.`,
 				printError("invalid arguments: nothing to add to the record %q", recordID)
 				return errSilent
 			}
-			service, err := records.NewService()
+			service, err := newService(cmd)
 			if err != nil {
 				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
+			applyChangeFlags(service, cmd)
 			record, err := addToRecord(service, recordID, tags, superseders)
 			if err != nil {
-				printError("unable to update ADR %q: %v", recordID, err)
+				printUpdateError(recordID, err)
 				return errSilent
 			}
-			if err := reportRecord(record, cmd.Bool("json")); err != nil {
+			if err := reportRecord(record, service.Workflow(), cmd.Bool("json")); err != nil {
 				printError("unable to encode record: %v", err)
 				return errSilent
 			}
@@ -66,21 +74,27 @@ It will keep the content and only modify the metadata.`,
 }
 
 func addToRecord(service *records.Service, recordID string, tags, superseders []string) (records.AdrData, error) {
-	record, ok := service.GetRecord(recordID)
-	if !ok {
-		return records.AdrData{}, errors.New("record not found")
+	record, err := resolveLocal(service, recordID)
+	if err != nil {
+		return records.AdrData{}, err
 	}
 	if len(tags) > 0 {
 		record.Tags.Append(tags...)
 	}
 	if len(superseders) > 0 {
-		warnUnknownRecords(service, superseders)
-		record.Superseders.Append(superseders...)
+		ids, err := resolveIDs(service, superseders)
+		if err != nil {
+			return records.AdrData{}, err
+		}
+		record.Superseders.Append(ids...)
 		// A record that now has superseders has, by definition, been superseded.
 		record.Status = records.SUPERSEDED
 	}
 	if err := service.UpdateRecord(record); err != nil {
 		return records.AdrData{}, err
 	}
+	if len(superseders) > 0 {
+		markSuperseded(service, record)
+	}
 	return record, nil
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/urfave/cli/v3"
+)
+
+func archiveCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "archive",
+		Usage:     "Move an ADR to the archive",
+		ArgsUsage: "<record ID>",
+		Description: `Move a record to the archive directory ("archive" in the ADR directory, or
+"archive_dir" in the configuration). Archived records keep their number and stay listed, so
+references to them remain valid, but they can no longer be changed.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Usage: "archive the record even if it changed on disk since it was read"},
+			&cli.BoolFlag{Name: "json", Usage: "print the archived record as JSON"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() == 0 {
+				missingArgument("record ID")
+				return errSilent
+			}
+			service, err := newService(cmd)
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
+			service.SetForce(cmd.Bool("force"))
+			record, ok := resolveRecord(service, cmd.Args().First())
+			if !ok {
+				return errSilent
+			}
+			archived, changed, err := service.ArchiveRecord(record)
+			if err != nil {
+				printUpdateError(record.ID, err)
+				return errSilent
+			}
+			refreshTOC(service)
+			if !cmd.Bool("json") {
+				fmt.Println(cs.Green("Record archived to %s", archived.Name))
+				if len(changed) > 0 {
+					fmt.Println(cs.Green("Links updated in %s", strings.Join(changed, ", ")))
+				}
+			}
+			if err := reportRecord(archived, service.Workflow(), cmd.Bool("json")); err != nil {
+				printError("unable to encode record: %v", err)
+				return errSilent
+			}
+			return nil
+		},
+	}
+}
+
+func deleteCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "delete",
+		Usage:     "Delete an ADR",
+		ArgsUsage: "<record ID>",
+		Description: `Delete a record file. It is refused when other records refer to it, as a superseder,
+through a link or with a [[...]] reference in their body, unless --cascade is given to remove those
+references too: a record left without superseders gets back the status it had before being
+superseded, and body references are replaced by their text. A record archived records refer to
+cannot be deleted.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "cascade", Usage: "remove the references the other records make to the record"},
+			&cli.StringFlag{Name: "reason", Usage: "reason recorded in the history of the records getting their status back"},
+			&cli.BoolFlag{Name: "force", Usage: "delete even if the records changed on disk since they were read"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() == 0 {
+				missingArgument("record ID")
+				return errSilent
+			}
+			service, err := newService(cmd)
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
+			applyChangeFlags(service, cmd)
+			record, ok := resolveRecord(service, cmd.Args().First())
+			if !ok {
+				return errSilent
+			}
+			changed, err := service.DeleteRecord(record, cmd.Bool("cascade"))
+			if err != nil {
+				printError("unable to delete ADR %q: %v", record.ID, err)
+				switch {
+				case errors.Is(err, records.ErrReferenced):
+					printWarning("re-run with --cascade to remove the references too")
+				case errors.Is(err, records.ErrConflict):
+					printWarning("re-run with --force to delete despite the changes made on disk")
+				}
+				return errSilent
+			}
+			refreshTOC(service)
+			fmt.Println(cs.Green("Record %q (%s) has been deleted", record.ID, record.Name))
+			if len(changed) > 0 {
+				fmt.Println(cs.Green("References removed from %s", strings.Join(changed, ", ")))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/urfave/cli/v3"
+)
+
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Manage the index cache",
+		Description: `Commands keep the parsed records in a .adr/cache file next to the
+configuration file, so unchanged record files (same size and modification time)
+are not parsed again. The cache is rebuilt as needed; clear it if it ever gets
+out of sync, or bypass it for one command with the global --no-cache flag.`,
+		Commands: []*cli.Command{
+			{
+				Name:  "clear",
+				Usage: "Remove the index cache of the project",
+				Action: func(_ context.Context, _ *cli.Command) error {
+					path, err := records.ClearCache()
+					if err != nil {
+						printError("unable to clear the cache: %v", err)
+						return errSilent
+					}
+					fmt.Println(cs.Green("Cache %q has been cleared", path))
+					return nil
+				},
+			},
+		},
+	}
+}
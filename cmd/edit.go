@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/exec"
 
-	"github.com/gwleclerc/adr/records"
 	"github.com/urfave/cli/v3"
 )
 
@@ -20,14 +19,13 @@ func editCommand() *cli.Command {
 				missingArgument("record ID")
 				return errSilent
 			}
-			service, err := records.NewService()
+			service, err := newService(cmd)
 			if err != nil {
 				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
-			record, ok := service.GetRecord(cmd.Args().First())
+			record, ok := resolveRecord(service, cmd.Args().First())
 			if !ok {
-				printError("record %q not found", cmd.Args().First())
 				return errSilent
 			}
 			if err := openEditor(service.RecordPath(record)); err != nil {
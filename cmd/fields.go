@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+)
+
+// parseFieldFlags parses `--field name=value` flags against the custom fields
+// declared in the configuration. An empty value maps to nil, meaning "unset".
+func parseFieldFlags(specs []cs.FieldSpec, flags []string) (map[string]any, error) {
+	values := make(map[string]any, len(flags))
+	for _, flag := range flags {
+		spec, raw, err := splitField(specs, flag)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(raw) == "" {
+			values[spec.Name] = nil
+			continue
+		}
+		v, err := records.ParseFieldValue(spec, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field %q: %w", spec.Name, err)
+		}
+		values[spec.Name] = v
+	}
+	return values, nil
+}
+
+// parseFieldFilters parses `--field name=value` list filters into the accepted
+// values of each field.
+func parseFieldFilters(specs []cs.FieldSpec, flags []string) (map[string][]string, error) {
+	filters := make(map[string][]string, len(flags))
+	for _, flag := range flags {
+		spec, raw, err := splitField(specs, flag)
+		if err != nil {
+			return nil, err
+		}
+		filters[spec.Name] = append(filters[spec.Name], splitCSV([]string{raw})...)
+	}
+	return filters, nil
+}
+
+func splitField(specs []cs.FieldSpec, flag string) (cs.FieldSpec, string, error) {
+	name, raw, ok := strings.Cut(flag, "=")
+	if !ok {
+		return cs.FieldSpec{}, "", fmt.Errorf("invalid field %q: expected name=value", flag)
+	}
+	name = strings.TrimSpace(name)
+	i := slices.IndexFunc(specs, func(spec cs.FieldSpec) bool { return spec.Name == name })
+	if i < 0 {
+		return cs.FieldSpec{}, "", fmt.Errorf("unknown field %q: declared fields: %s", name, fieldNames(specs))
+	}
+	return specs[i], raw, nil
+}
+
+// applyFields writes custom field values into a record, in declaration order.
+// A nil value removes the field.
+func applyFields(record *records.AdrData, specs []cs.FieldSpec, values map[string]any) error {
+	for _, spec := range specs {
+		v, ok := values[spec.Name]
+		switch {
+		case !ok:
+		case v == nil && spec.Required:
+			return fmt.Errorf("field %q is required and cannot be removed", spec.Name)
+		case v == nil:
+			record.Extra.Delete(spec.Name)
+		default:
+			if err := record.Extra.Set(spec.Name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldMatches reports whether a record's field holds one of the wanted values
+// (any item, for a list).
+func fieldMatches(record records.AdrData, name string, wanted []string) bool {
+	v, ok := record.Extra.Get(name)
+	if !ok {
+		return false
+	}
+	if items, isList := v.([]any); isList {
+		for _, item := range items {
+			if slices.Contains(wanted, records.FormatFieldValue(item)) {
+				return true
+			}
+		}
+		return false
+	}
+	return slices.Contains(wanted, records.FormatFieldValue(v))
+}
+
+func fieldNames(specs []cs.FieldSpec) string {
+	if len(specs) == 0 {
+		return "none"
+	}
+	names := make([]string, len(specs))
+	for i, spec := range specs {
+		names[i] = spec.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/gwleclerc/adr/records"
+	"github.com/gwleclerc/adr/utils"
+	"github.com/urfave/cli/v3"
+)
+
+// supersedesRelation is the label of the edges between a record and the
+// records it supersedes.
+const supersedesRelation = "supersedes"
+
+// graphFills are the fill colors of the nodes, by status color name.
+var graphFills = map[string]string{
+	"red":     "#f4cccc",
+	"green":   "#d9ead3",
+	"yellow":  "#fff2cc",
+	"grey":    "#eeeeee",
+	"blue":    "#cfe2f3",
+	"magenta": "#ead1dc",
+	"cyan":    "#d0e0e3",
+}
+
+func graphCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "graph",
+		Usage: "Print the graph of the ADRs and how they relate",
+		Description: `Print the records as a graph: an edge from each record to the records it supersedes,
+and one per link between records (see "adr link"), labeled with its relation. Nodes are colored
+by status, as in "adr list". The graph is printed in Graphviz DOT (render it with
+"adr graph | dot -Tsvg > adrs.svg"), as a Mermaid flowchart, or as JSON nodes and edges.
+Filters select the records like for "adr list"; edges to records left out are dropped.`,
+		DisableSliceFlagSeparator: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "format",
+				Aliases: []string{"f"},
+				Usage:   `output format: "dot", "mermaid" or "json"`,
+				Value:   "dot",
+			},
+			&cli.StringSliceFlag{Name: "authors", Aliases: []string{"a"}, Usage: "filter records by authors"},
+			&cli.StringSliceFlag{Name: "status", Aliases: []string{"s"}, Usage: "filter records by status"},
+			&cli.StringSliceFlag{Name: "tags", Aliases: []string{"t"}, Usage: "filter records by tags"},
+			&cli.StringSliceFlag{Name: "category", Aliases: []string{"c"}, Usage: "filter records by category (including its subcategories)"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			format := cmd.String("format")
+			if !slices.Contains([]string{"dot", "mermaid", "json"}, format) {
+				printError(`invalid format %q: must be "dot", "mermaid" or "json"`, format)
+				return errSilent
+			}
+			service, err := newService(cmd, records.WithMetadataOnly())
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
+			adrs := filterRecords(service.GetRecords(), listFilters{
+				authors:    splitCSV(cmd.StringSlice("authors")),
+				status:     splitCSV(cmd.StringSlice("status")),
+				tags:       splitCSV(cmd.StringSlice("tags")),
+				categories: splitCSV(cmd.StringSlice("category")),
+			})
+			g := buildGraph(adrs, service.Relations(), service.Workflow())
+			switch format {
+			case "json":
+				if err := printJSON(g); err != nil {
+					printError("unable to encode graph: %v", err)
+					return errSilent
+				}
+			case "mermaid":
+				fmt.Print(g.mermaid())
+			default:
+				fmt.Print(g.dot())
+			}
+			return nil
+		},
+	}
+}
+
+// graph is the graph of records printed by the graph command.
+type graph struct {
+	Nodes []graphNode `json:"nodes"`
+	Edges []graphEdge `json:"edges"`
+}
+
+type graphNode struct {
+	ID     string            `json:"id"`
+	Label  string            `json:"label"`
+	Title  string            `json:"title"`
+	Status records.AdrStatus `json:"status"`
+	// Color is the name of the color of the status (see "statuses" in the
+	// configuration).
+	Color string `json:"color"`
+	File  string `json:"file"`
+}
+
+// graphEdge goes from a record to a record it supersedes ("supersedes") or is
+// linked to, the relation being read from the first one.
+type graphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// buildGraph builds the graph of the records, with an edge per supersession and
+// per link between them, whichever side of the pair stores it. A link stored
+// under an inverse relation name is read the other way round ("amended-by"
+// becomes "amends"), and a link with a symmetric relation goes from the first
+// record to the other. Nodes take the colors of their status in the workflow.
+func buildGraph(adrs []records.AdrData, relations records.Relations, workflow records.Workflow) graph {
+	g := graph{Nodes: []graphNode{}, Edges: []graphEdge{}}
+	position := map[string]int{}
+	for i, a := range adrs {
+		position[a.ID] = i
+		// Drafts are not numbered, even when their name starts with digits.
+		label := a.ID
+		if number := utils.GetRecordNumber(a.Name); number != "" && !a.Draft {
+			label = number
+		}
+		g.Nodes = append(g.Nodes, graphNode{
+			ID:     a.ID,
+			Label:  label + " " + a.Title,
+			Title:  a.Title,
+			Status: a.Status,
+			Color:  workflow.Color(a.Status),
+			File:   a.Name,
+		})
+	}
+	seen := map[graphEdge]bool{}
+	add := func(e graphEdge) {
+		from, okFrom := position[e.From]
+		to, okTo := position[e.To]
+		if !okFrom || !okTo || seen[e] {
+			return
+		}
+		if r, ok := relations.Lookup(e.Relation); ok && r.Name == r.Inverse && from > to {
+			e.From, e.To = e.To, e.From
+			if seen[e] {
+				return
+			}
+		}
+		seen[e] = true
+		g.Edges = append(g.Edges, e)
+	}
+	primary := map[string]bool{}
+	for _, r := range relations {
+		primary[r.Name] = true
+	}
+	for _, a := range adrs {
+		for _, id := range a.Superseders.ToSlice() {
+			add(graphEdge{From: id, To: a.ID, Relation: supersedesRelation})
+		}
+		for _, id := range a.Supersedes.ToSlice() {
+			add(graphEdge{From: a.ID, To: id, Relation: supersedesRelation})
+		}
+		for _, name := range slices.Sorted(maps.Keys(a.Links)) {
+			for _, id := range a.Links[name].ToSlice() {
+				if r, ok := relations.Lookup(name); ok && !primary[name] {
+					add(graphEdge{From: id, To: a.ID, Relation: r.Inverse})
+				} else {
+					add(graphEdge{From: a.ID, To: id, Relation: name})
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(g.Edges, func(a, b graphEdge) int {
+		if c := position[a.From] - position[b.From]; c != 0 {
+			return c
+		}
+		return position[a.To] - position[b.To]
+	})
+	return g
+}
+
+// dot renders the graph in Graphviz DOT. Links are dashed, supersessions solid.
+func (g graph) dot() string {
+	var b strings.Builder
+	b.WriteString("digraph adrs {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\"];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, fillcolor=%q];\n", dotQuote(n.ID), dotQuote(n.Label+"\n"+string(n.Status)), graphFills[n.Color])
+	}
+	for _, e := range g.Edges {
+		style := ""
+		if e.Relation != supersedesRelation {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Relation), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// mermaid renders the graph as a Mermaid flowchart. Links are dotted,
+// supersessions solid.
+func (g graph) mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]\n", ids[n.ID], mermaidEscape(n.Label), mermaidEscape(string(n.Status)))
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Relation != supersedesRelation {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[e.From], arrow, mermaidEscape(e.Relation), ids[e.To])
+	}
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  style %s fill:%s\n", ids[n.ID], graphFills[n.Color])
+	}
+	return b.String()
+}
+
+// mermaidEscape escapes the characters that end a Mermaid label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// renderTOCGraph renders the graph of the records listed in an index as a
+// Mermaid diagram, in a section to append to the index ("" without records).
+func renderTOCGraph(adrs []records.AdrData, relations records.Relations, workflow records.Workflow) string {
+	adrs = indexedRecords(adrs)
+	if len(adrs) == 0 {
+		return ""
+	}
+	return "\n## Graph\n\n```mermaid\n" + buildGraph(adrs, relations, workflow).mermaid() + "```\n"
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+)
+
+func TestBuildGraph(t *testing.T) {
+	a := mkFull("001_a.md", "a", "A", records.SUPERSEDED, "b")
+	b := mkFull("002_b.md", "b", "B", records.ACCEPTED)
+	b.Supersedes.Append("a")
+	c := mkFull("003_c.md", "c", "C", records.ACCEPTED)
+	// Both sides of each link are stored: each must give a single edge.
+	c.AddLink("amends", "b")
+	b.AddLink("amended-by", "c")
+	c.AddLink("relates-to", "a")
+	a.AddLink("relates-to", "c")
+	// Links to records left out of the graph are dropped.
+	c.AddLink("depends-on", "ghost")
+	// Drafts are labeled with their ID, whatever their name.
+	d := mkFull("drafts/2024_roadmap.md", "d", "Roadmap", records.AdrStatus("in-review"))
+	d.Draft = true
+	workflow, err := records.NewWorkflow([]cs.StatusSpec{{Name: "in-review", Color: "blue"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := buildGraph([]records.AdrData{a, b, c, d}, records.DefaultRelations(), workflow)
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, e.From+" "+e.Relation+" "+e.To)
+	}
+	want := []string{"a relates-to c", "b supersedes a", "c amends b"}
+	if !slices.Equal(edges, want) {
+		t.Errorf("edges = %v, want %v", edges, want)
+	}
+	if len(g.Nodes) != 4 || g.Nodes[0].Label != "001 A" || g.Nodes[1].Color != "green" || g.Nodes[3].Label != "d Roadmap" || g.Nodes[3].Color != "blue" {
+		t.Errorf("nodes = %+v", g.Nodes)
+	}
+
+	for _, tt := range []struct {
+		name, out string
+		want      []string
+	}{
+		{"dot", g.dot(), []string{`"b" -> "a" [label="supersedes"];`, `"c" -> "b" [label="amends", style=dashed];`, `fillcolor="#d9ead3"`}},
+		{"mermaid", g.mermaid(), []string{"flowchart LR\n", `n0["001 A<br/>superseded"]`, "n1 -->|supersedes| n0", "n2 -.->|amends| n1", "style n1 fill:#d9ead3"}},
+	} {
+		for _, w := range tt.want {
+			if !strings.Contains(tt.out, w) {
+				t.Errorf("%s output misses %q\n%s", tt.name, w, tt.out)
+			}
+		}
+	}
+}
@@ -12,28 +12,32 @@ func deprecateCommand() *cli.Command {
 		Name:      "deprecate",
 		Usage:     "Mark an ADR as deprecated",
 		ArgsUsage: "<record ID>",
-		Flags:     []cli.Flag{&cli.BoolFlag{Name: "json", Usage: "print the updated record as JSON"}},
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "reason", Usage: "reason for the status change, recorded in the history"},
+			&cli.BoolFlag{Name: "force", Usage: "overwrite the record even if it changed on disk, and allow any status change"},
+			&cli.BoolFlag{Name: "json", Usage: "print the updated record as JSON"},
+		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() == 0 {
 				missingArgument("record ID")
 				return errSilent
 			}
-			service, err := records.NewService()
+			service, err := newService(cmd)
 			if err != nil {
 				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
-			record, ok := service.GetRecord(cmd.Args().First())
+			applyChangeFlags(service, cmd)
+			record, ok := resolveRecord(service, cmd.Args().First())
 			if !ok {
-				printError("record %q not found", cmd.Args().First())
 				return errSilent
 			}
 			record.Status = records.DEPRECATED
 			if err := service.UpdateRecord(record); err != nil {
-				printError("unable to update ADR %q: %v", record.ID, err)
+				printUpdateError(record.ID, err)
 				return errSilent
 			}
-			if err := reportRecord(record, cmd.Bool("json")); err != nil {
+			if err := reportRecord(record, service.Workflow(), cmd.Bool("json")); err != nil {
 				printError("unable to encode record: %v", err)
 				return errSilent
 			}
@@ -47,33 +51,40 @@ func supersedeCommand() *cli.Command {
 		Name:      "supersede",
 		Usage:     "Mark an ADR as superseded by another",
 		ArgsUsage: "<superseded ID> <superseder ID>",
-		Flags:     []cli.Flag{&cli.BoolFlag{Name: "json", Usage: "print the updated record as JSON"}},
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "reason", Usage: "reason for the status change, recorded in the history"},
+			&cli.BoolFlag{Name: "force", Usage: "overwrite the record even if it changed on disk, and allow any status change"},
+			&cli.BoolFlag{Name: "json", Usage: "print the updated record as JSON"},
+		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() < 2 {
 				printError("supersede requires <superseded ID> and <superseder ID>")
 				return errSilent
 			}
 			supersededID, supersederID := cmd.Args().Get(0), cmd.Args().Get(1)
-			service, err := records.NewService()
+			service, err := newService(cmd)
 			if err != nil {
 				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
-			record, ok := service.GetRecord(supersededID)
+			applyChangeFlags(service, cmd)
+			record, ok := resolveRecord(service, supersededID)
 			if !ok {
-				printError("record %q not found", supersededID)
 				return errSilent
 			}
-			if _, ok := service.GetRecord(supersederID); !ok {
-				printWarning("superseder %q does not match any existing record", supersederID)
+			superseders, err := resolveIDs(service, []string{supersederID})
+			if err != nil {
+				printError("invalid superseder: %v", err)
+				return errSilent
 			}
 			record.Status = records.SUPERSEDED
-			record.Superseders.Append(supersederID)
+			record.Superseders.Append(superseders...)
 			if err := service.UpdateRecord(record); err != nil {
-				printError("unable to update ADR %q: %v", record.ID, err)
+				printUpdateError(record.ID, err)
 				return errSilent
 			}
-			if err := reportRecord(record, cmd.Bool("json")); err != nil {
+			markSuperseded(service, record)
+			if err := reportRecord(record, service.Workflow(), cmd.Bool("json")); err != nil {
 				printError("unable to encode record: %v", err)
 				return errSilent
 			}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/urfave/cli/v3"
+)
+
+func lineageCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "lineage",
+		Usage:     "Print the supersession history of an ADR",
+		ArgsUsage: "<record ID>",
+		Description: `Print the chain of records a decision belongs to, from the original decisions to the
+ones in force: the records it supersedes and is superseded by, theirs, and so on. A record
+superseded by several ones opens a branch per superseder. Supersessions stored on either side
+of a pair are followed, across collections.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "json", Usage: "print the lineage as JSON, each record before its superseders"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			service, record, lineage, ok := loadLineage(cmd)
+			if !ok {
+				return errSilent
+			}
+			if cmd.Bool("json") {
+				out := make([]lineageOutput, len(lineage))
+				for i, e := range lineage {
+					out[i] = newLineageOutput(e)
+				}
+				if err := printJSON(out); err != nil {
+					printError("unable to encode lineage: %v", err)
+					return errSilent
+				}
+				return nil
+			}
+			fmt.Print(renderLineage(lineage, service.Ref(record), collectionWorkflows(service)))
+			return nil
+		},
+	}
+}
+
+func currentCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "current",
+		Usage:     "Print the ADRs now in force in place of an ADR",
+		ArgsUsage: "<record ID>",
+		Description: `Follow the superseders of a record to the records that nothing supersedes: the decisions
+now in force. A record that is not superseded is its own current record.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "json", Usage: "print the records as JSON"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			service, record, lineage, ok := loadLineage(cmd)
+			if !ok {
+				return errSilent
+			}
+			var current []records.AdrData
+			for _, e := range lineage.Current(service.Ref(record)) {
+				current = append(current, e.Record)
+			}
+			if cmd.Bool("json") {
+				if err := printJSON(current); err != nil {
+					printError("unable to encode records: %v", err)
+					return errSilent
+				}
+				return nil
+			}
+			renderTable(current, nil, collectionWorkflows(service))
+			return nil
+		},
+	}
+}
+
+// loadLineage resolves the record given as argument, possibly of another
+// collection, and returns its lineage, printing why when it cannot.
+func loadLineage(cmd *cli.Command) (*records.Service, records.AdrData, records.Lineage, bool) {
+	if cmd.Args().Len() == 0 {
+		missingArgument("record ID")
+		return nil, records.AdrData{}, nil, false
+	}
+	service, err := newService(cmd, records.WithMetadataOnly())
+	if err != nil {
+		printError("unable to initialize records service: %v", err)
+		return nil, records.AdrData{}, nil, false
+	}
+	record, err := service.Resolve(cmd.Args().First())
+	if err != nil {
+		printError("%v", err)
+		return nil, records.AdrData{}, nil, false
+	}
+	lineage, err := service.Lineage(record)
+	if err != nil {
+		printError("unable to follow the supersessions of %q: %v", record.ID, err)
+		if errors.Is(err, records.ErrCycle) {
+			printWarning(`fix the "superseders" and "supersedes" of these records, "adr lint" reports them`)
+		}
+		return nil, records.AdrData{}, nil, false
+	}
+	return service, record, lineage, true
+}
+
+// lineageOutput is a record of the JSON output of lineage.
+type lineageOutput struct {
+	Ref          string            `json:"ref"`
+	ID           string            `json:"id"`
+	Title        string            `json:"title"`
+	Status       records.AdrStatus `json:"status"`
+	File         string            `json:"file"`
+	Collection   string            `json:"collection,omitempty"`
+	Supersedes   []string          `json:"supersedes,omitempty"`
+	SupersededBy []string          `json:"superseded_by,omitempty"`
+	// Current is set on the records nothing supersedes.
+	Current bool `json:"current"`
+}
+
+func newLineageOutput(e records.LineageEntry) lineageOutput {
+	return lineageOutput{
+		Ref:          e.Ref,
+		ID:           e.Record.ID,
+		Title:        e.Record.Title,
+		Status:       e.Record.Status,
+		File:         e.Record.Name,
+		Collection:   e.Record.Collection,
+		Supersedes:   e.Supersedes,
+		SupersededBy: e.SupersededBy,
+		Current:      len(e.SupersededBy) == 0,
+	}
+}
+
+// renderLineage renders a lineage as a tree per original record, each record
+// followed by its superseders. A record reached again through another branch
+// is not expanded twice. The record the lineage was asked for is marked. Statuses
+// are colored as in the workflow of each record's collection.
+func renderLineage(lineage records.Lineage, ref string, workflow func(records.AdrData) records.Workflow) string {
+	var b strings.Builder
+	shown := map[string]bool{}
+	var render func(e records.LineageEntry, indent, branch string)
+	render = func(e records.LineageEntry, indent, branch string) {
+		line := fmt.Sprintf("%s %s (%s)", e.Ref, e.Record.Title, workflow(e.Record).Colorize(e.Record.Status))
+		switch {
+		case shown[e.Ref]:
+			fmt.Fprintf(&b, "%s%s%s%s\n", indent, branch, line, cs.Grey(" (see above)"))
+			return
+		case e.Ref == ref:
+			line += cs.Green(" <")
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", indent, branch, line)
+		shown[e.Ref] = true
+		switch branch {
+		case "├── ":
+			indent += "│   "
+		case "└── ":
+			indent += "    "
+		}
+		for i, superseder := range e.SupersededBy {
+			next, _ := lineage.Get(superseder)
+			if i == len(e.SupersededBy)-1 {
+				render(next, indent, "└── ")
+			} else {
+				render(next, indent, "├── ")
+			}
+		}
+	}
+	for _, origin := range lineage.Origins() {
+		render(origin, "", "")
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gwleclerc/adr/records"
+)
+
+func TestRenderLineage(t *testing.T) {
+	entry := func(ref string, supersedes, supersededBy []string) records.LineageEntry {
+		return records.LineageEntry{Ref: ref, Record: records.AdrData{ID: ref, Title: strings.ToUpper(ref), Status: records.ACCEPTED}, Supersedes: supersedes, SupersededBy: supersededBy}
+	}
+	// a and b were merged into c, which d and e replaced.
+	lineage := records.Lineage{
+		entry("a", nil, []string{"c"}),
+		entry("b", nil, []string{"c"}),
+		entry("c", []string{"a", "b"}, []string{"d", "e"}),
+		entry("d", []string{"c"}, nil),
+		entry("e", []string{"c"}, nil),
+	}
+	out := renderLineage(lineage, "c", func(records.AdrData) records.Workflow { return records.DefaultWorkflow() })
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []string{
+		"a A (",
+		"└── c C (",
+		"    ├── d D (",
+		"    └── e E (",
+		"b B (",
+		"└── c C (",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lineage has %d lines, want %d\n%s", len(lines), len(want), out)
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	if !strings.Contains(lines[1], "<") || !strings.Contains(lines[5], "see above") {
+		t.Errorf("the asked record should be marked, and not expanded twice\n%s", out)
+	}
+}
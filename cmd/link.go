@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/urfave/cli/v3"
+)
+
+func linkCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "link",
+		Usage:     "Link an ADR to another one",
+		ArgsUsage: "<record ID> <relation> <target ID>",
+		Description: `Record how a decision relates to another one, e.g. "adr link 7 amends 3". The link is
+stored on both records: under the relation on the first one, and under its inverse on the
+target (here "amended-by"). The built-in relations are amends/amended-by,
+refines/refined-by, depends-on/required-by, conflicts-with and relates-to; more can be
+declared under "relations" in the configuration. Linking records again completes a link
+missing on one side. The target may belong to another collection ("payments:007").`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Usage: "overwrite the records even if they changed on disk"},
+			&cli.BoolFlag{Name: "json", Usage: "print the updated record as JSON"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return changeLink(cmd, true)
+		},
+	}
+}
+
+func unlinkCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "unlink",
+		Usage:       "Remove a link between two ADRs",
+		ArgsUsage:   "<record ID> <relation> <target ID>",
+		Description: `Remove a link made with "adr link", from both records.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "force", Usage: "overwrite the records even if they changed on disk"},
+			&cli.BoolFlag{Name: "json", Usage: "print the updated record as JSON"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return changeLink(cmd, false)
+		},
+	}
+}
+
+// changeLink runs link (add) and unlink.
+func changeLink(cmd *cli.Command, add bool) error {
+	if cmd.Args().Len() < 3 {
+		printError("%s requires <record ID>, <relation> and <target ID>", cmd.Name)
+		return errSilent
+	}
+	service, err := newService(cmd)
+	if err != nil {
+		printError("unable to initialize records service: %v", err)
+		return errSilent
+	}
+	applyChangeFlags(service, cmd)
+	record, ok := resolveRecord(service, cmd.Args().Get(0))
+	if !ok {
+		return errSilent
+	}
+	relation := cmd.Args().Get(1)
+	target, err := service.Resolve(cmd.Args().Get(2))
+	if err != nil {
+		printError("invalid target: %v", err)
+		return errSilent
+	}
+
+	change, done := service.Link, "linked to"
+	if !add {
+		change, done = service.Unlink, "unlinked from"
+	}
+	if err := change(record, relation, target); err != nil {
+		var relationErr *records.RelationError
+		if errors.As(err, &relationErr) {
+			printError("%v", err)
+		} else {
+			printUpdateError(record.ID, err)
+		}
+		return errSilent
+	}
+	record, _ = service.GetRecord(record.ID)
+	if cmd.Bool("json") {
+		if err := printJSON(record); err != nil {
+			printError("unable to encode record: %v", err)
+			return errSilent
+		}
+		return nil
+	}
+	fmt.Println(cs.Green("Record %q %s %q (%s)", record.ID, done, service.Ref(target), relation))
+	return nil
+}
+
+// renderLinks renders the links of a record, followed by the links only the
+// linked records store (inferred), each target described by describe. It
+// returns "" when there are none.
+func renderLinks(links map[string]records.Set[string], inferred map[string][]string, describe func(ref string) string) string {
+	type line struct{ relation, ref, note string }
+	var lines []line
+	for relation, targets := range links {
+		for _, ref := range targets.ToSlice() {
+			lines = append(lines, line{relation, ref, ""})
+		}
+	}
+	for relation, refs := range inferred {
+		for _, ref := range refs {
+			lines = append(lines, line{relation, ref, cs.Grey(" (inferred)")})
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	slices.SortFunc(lines, func(a, b line) int {
+		return strings.Compare(a.relation+" "+a.ref, b.relation+" "+b.ref)
+	})
+	width := 0
+	for _, l := range lines {
+		width = max(width, len(l.relation))
+	}
+	var b strings.Builder
+	b.WriteString("Links:\n")
+	for _, l := range lines {
+		fmt.Fprintf(&b, "  %-*s  %s%s\n", width, l.relation, describe(l.ref), l.note)
+	}
+	return b.String()
+}
+
+// describeRef describes a linked record by its reference and title, or as
+// missing when it does not exist.
+func describeRef(service *records.Service) func(ref string) string {
+	return func(ref string) string {
+		record, err := service.Resolve(ref)
+		if err != nil || service.Ref(record) != ref {
+			return ref + cs.Red(" (missing)")
+		}
+		return fmt.Sprintf("%s %s", ref, record.Title)
+	}
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"path"
 	"slices"
 	"sort"
 	"strings"
@@ -15,6 +18,7 @@ import (
 
 type lintIssue struct {
 	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
 	Rule    string `json:"rule"`
 	Message string `json:"message"`
 }
@@ -24,18 +28,57 @@ func lintCommand() *cli.Command {
 		Name:  "lint",
 		Usage: "Check the ADRs for consistency problems",
 		Description: `Report inconsistencies across records: dangling superseder references,
-duplicate numbers, invalid statuses, superseders without a superseded status, and missing
-titles. Exits non-zero when any issue is found (useful in CI).`,
+duplicate numbers, statuses not declared in the workflow, superseders without a superseded status and
+superseded statuses without superseders, missing titles, custom fields that are missing or invalid,
+and links with an unknown relation, to a record that does not exist, or missing on the linked record
+(re-run "adr link" to complete it). A supersession must be recorded on both records: "superseders"
+on the superseded one, "supersedes" on the superseder, and records must not supersede each other
+in a loop. References written in bodies ("[[ADR-004]]") must designate a record. Files that cannot
+be parsed are reported too (unreadable, bad-yaml, bad-date, bad-field), as are records left under a
+temporary name by an interrupted "adr renumber" (renumber-leftover). Exits non-zero when any issue
+is found (useful in CI).
+
+With --fix, the missing side of each supersession is added first (the superseded record taking the
+superseded status).`,
 		Flags: []cli.Flag{
 			&cli.BoolFlag{Name: "json", Usage: "output issues as JSON"},
+			&cli.BoolFlag{Name: "fix", Usage: "complete the supersessions recorded on one record only"},
+			&cli.BoolFlag{Name: "force", Usage: "with --fix, overwrite records even if they changed on disk, and allow any status change"},
 		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
-			service, err := records.NewService()
+			service, err := loadService(cmd, records.WithMetadataOnly())
 			if err != nil {
 				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
-			issues := lintRecords(service.GetRecords())
+			if cmd.Bool("fix") {
+				applyChangeFlags(service, cmd)
+				fixed, err := fixSupersession(service)
+				if !cmd.Bool("json") {
+					for _, name := range fixed {
+						fmt.Println(cs.Green("Fixed %s: completed its supersession", name))
+					}
+				}
+				if err != nil {
+					printWarning("unable to fix every supersession: %v", err)
+				}
+			}
+			issues := append(diagnosticIssues(service.Diagnostics()), lintRecords(service.GetRecords(), lintOptions{
+				workflow:    service.Workflow(),
+				fields:      service.Fields(),
+				perCategory: service.PerCategoryNumbering(),
+				exists:      service.Exists,
+				relations:   service.Relations(),
+				references: func(a records.AdrData) []records.Reference {
+					// Unreadable files are reported as diagnostics already.
+					refs, _ := service.FileReferences(a)
+					return refs
+				},
+				resolveReference: func(ref string) error {
+					_, err := service.LookupReference(ref)
+					return err
+				},
+			})...)
 
 			if cmd.Bool("json") {
 				if err := printJSON(issues); err != nil {
@@ -46,7 +89,11 @@ titles. Exits non-zero when any issue is found (useful in CI).`,
 				fmt.Println(cs.Green("No issues found."))
 			} else {
 				for _, is := range issues {
-					fmt.Println(cs.Yellow("%s: %s (%s)", is.File, is.Message, is.Rule))
+					file := is.File
+					if is.Line > 0 {
+						file = fmt.Sprintf("%s:%d", file, is.Line)
+					}
+					fmt.Println(cs.Yellow("%s: %s (%s)", file, is.Message, is.Rule))
 				}
 			}
 			if len(issues) > 0 {
@@ -57,38 +104,85 @@ titles. Exits non-zero when any issue is found (useful in CI).`,
 	}
 }
 
+// lintOptions describes the project the records are checked against.
+type lintOptions struct {
+	// workflow declares the statuses records may have.
+	workflow records.Workflow
+	// fields are the declared custom fields.
+	fields []cs.FieldSpec
+	// perCategory makes numbers only unique within a category.
+	perCategory bool
+	// exists resolves superseders that are not among the records, such as
+	// references to other collections ("payments:ID"). Nil means none exist.
+	exists func(ref string) bool
+	// relations are the relation types links can be made with.
+	relations records.Relations
+	// references returns the references to records written in the body of a
+	// record ("[[ADR-004]]"), with their line in the file, and resolveReference
+	// reports why one designates no record. Nil means bodies are not checked.
+	references       func(records.AdrData) []records.Reference
+	resolveReference func(ref string) error
+}
+
+// diagnosticIssues reports the files that could not be parsed, the rule being
+// the kind of problem.
+func diagnosticIssues(diagnostics []records.Diagnostic) []lintIssue {
+	issues := make([]lintIssue, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		issues = append(issues, lintIssue{File: d.File, Line: d.Line, Rule: string(d.Kind), Message: d.Message})
+	}
+	return issues
+}
+
 // lintRecords returns every consistency problem found across the records.
-func lintRecords(adrs []records.AdrData) []lintIssue {
-	ids := make(map[string]bool, len(adrs))
+func lintRecords(adrs []records.AdrData, opts lintOptions) []lintIssue {
+	byID := make(map[string]records.AdrData, len(adrs))
 	for _, a := range adrs {
-		ids[a.ID] = true
+		byID[a.ID] = a
 	}
 
 	numbers := map[string][]string{}
 	issues := []lintIssue{}
 	for _, a := range adrs {
 		if a.Title == "" {
-			issues = append(issues, lintIssue{a.Name, "missing-title", "record has no title"})
-		}
-		if !slices.Contains(records.AdrStatuses, a.Status) {
-			issues = append(issues, lintIssue{a.Name, "invalid-status", fmt.Sprintf("unknown status %q", a.Status)})
+			issues = append(issues, lintIssue{File: a.Name, Rule: "missing-title", Message: "record has no title"})
 		}
-		for superseder := range a.Superseders {
-			if !ids[superseder] {
-				issues = append(issues, lintIssue{a.Name, "dangling-superseder", fmt.Sprintf("superseder %q does not exist", superseder)})
-			}
+		if !opts.workflow.Declared(a.Status) {
+			issues = append(issues, lintIssue{File: a.Name, Rule: "invalid-status", Message: fmt.Sprintf("status %q is not declared", a.Status)})
 		}
+		issues = append(issues, lintSupersession(a, byID, opts)...)
+		issues = append(issues, lintLinks(a, byID, opts)...)
+		issues = append(issues, lintReferences(a, opts)...)
 		if len(a.Superseders) > 0 && a.Status != records.SUPERSEDED {
-			issues = append(issues, lintIssue{a.Name, "inconsistent-status", fmt.Sprintf("has superseders but status is %q, not superseded", a.Status)})
+			issues = append(issues, lintIssue{File: a.Name, Rule: "inconsistent-status", Message: fmt.Sprintf("has superseders but status is %q, not superseded", a.Status)})
+		}
+		if len(a.Superseders) == 0 && a.Status == records.SUPERSEDED {
+			issues = append(issues, lintIssue{File: a.Name, Rule: "missing-superseders", Message: "status is superseded but no superseder is set"})
+		}
+		for _, spec := range opts.fields {
+			v, ok := a.Extra.Get(spec.Name)
+			if !ok {
+				if spec.Required {
+					issues = append(issues, lintIssue{File: a.Name, Rule: "missing-field", Message: fmt.Sprintf("required field %q is not set", spec.Name)})
+				}
+				continue
+			}
+			if _, err := records.NormalizeFieldValue(spec, v); err != nil {
+				issues = append(issues, lintIssue{File: a.Name, Rule: "invalid-field", Message: fmt.Sprintf("field %q: %v", spec.Name, err)})
+			}
 		}
-		if number := utils.GetRecordNumber(a.Name); number != "" {
+		if number := utils.GetRecordNumber(a.Name); number != "" && !a.Draft {
+			if opts.perCategory {
+				number = path.Join(a.Category, number)
+			}
 			numbers[number] = append(numbers[number], a.Name)
 		}
 	}
+	issues = append(issues, lintCycles(adrs, byID)...)
 	for number, files := range numbers {
 		if len(files) > 1 {
 			sort.Strings(files)
-			issues = append(issues, lintIssue{files[0], "duplicate-number", fmt.Sprintf("number %s is used by %s", number, strings.Join(files, ", "))})
+			issues = append(issues, lintIssue{File: files[0], Rule: "duplicate-number", Message: fmt.Sprintf("number %s is used by %s", number, strings.Join(files, ", "))})
 		}
 	}
 
@@ -100,3 +194,141 @@ func lintRecords(adrs []records.AdrData) []lintIssue {
 	})
 	return issues
 }
+
+// lintSupersession checks the supersessions of a record: the records it refers
+// to must exist and, when they are among the records and not archived, refer
+// back to it under the inverse key.
+func lintSupersession(a records.AdrData, byID map[string]records.AdrData, opts lintOptions) []lintIssue {
+	var issues []lintIssue
+	check := func(key, rule string, refs records.Set[string], back func(records.AdrData) records.Set[string], inverse string) {
+		for _, ref := range refs.ToSlice() {
+			target, ok := byID[ref]
+			if !ok {
+				if opts.exists == nil || !opts.exists(ref) {
+					issues = append(issues, lintIssue{File: a.Name, Rule: rule, Message: fmt.Sprintf("%s %q does not exist", key, ref)})
+				}
+				continue
+			}
+			if !target.Archived && !back(target)[a.ID] {
+				issues = append(issues, lintIssue{File: a.Name, Rule: "asymmetric-supersession", Message: fmt.Sprintf("%s %q: %s does not list it under %s (run \"adr lint --fix\")", key, ref, target.Name, inverse)})
+			}
+		}
+	}
+	check("superseder", "dangling-superseder", a.Superseders, func(r records.AdrData) records.Set[string] { return r.Supersedes }, "supersedes")
+	check("superseded record", "dangling-supersedes", a.Supersedes, func(r records.AdrData) records.Set[string] { return r.Superseders }, "superseders")
+	return issues
+}
+
+// lintReferences checks that the references to records written in the body of
+// a record designate a single record.
+func lintReferences(a records.AdrData, opts lintOptions) []lintIssue {
+	if opts.references == nil || opts.resolveReference == nil {
+		return nil
+	}
+	var issues []lintIssue
+	for _, ref := range opts.references(a) {
+		if err := opts.resolveReference(ref.Ref); err != nil {
+			issues = append(issues, lintIssue{File: a.Name, Line: ref.Line, Rule: "unknown-reference", Message: fmt.Sprintf("reference [[%s]]: %v", ref.Ref, err)})
+		}
+	}
+	return issues
+}
+
+// lintCycles reports the records superseding each other in a loop, once per
+// loop, on the file of the first record. Supersessions stored on either side of
+// a pair are followed.
+func lintCycles(adrs []records.AdrData, byID map[string]records.AdrData) []lintIssue {
+	superseders := map[string]records.Set[string]{}
+	for _, a := range adrs {
+		for id := range a.Superseders {
+			if _, ok := byID[id]; ok {
+				set := superseders[a.ID]
+				set.Append(id)
+				superseders[a.ID] = set
+			}
+		}
+		for id := range a.Supersedes {
+			if _, ok := byID[id]; ok {
+				set := superseders[id]
+				set.Append(a.ID)
+				superseders[id] = set
+			}
+		}
+	}
+	reach := func(from string) records.Set[string] {
+		seen := records.Set[string]{}
+		for queue := superseders[from].ToSlice(); len(queue) > 0; queue = queue[1:] {
+			if id := queue[0]; !seen[id] {
+				seen.Append(id)
+				queue = append(queue, superseders[id].ToSlice()...)
+			}
+		}
+		return seen
+	}
+	reached := make(map[string]records.Set[string], len(adrs))
+	for _, a := range adrs {
+		reached[a.ID] = reach(a.ID)
+	}
+
+	var issues []lintIssue
+	reported := map[string]bool{}
+	for _, a := range adrs {
+		if reported[a.ID] || !reached[a.ID][a.ID] {
+			continue
+		}
+		var loop []string
+		for _, b := range adrs {
+			if reached[a.ID][b.ID] && reached[b.ID][a.ID] {
+				loop = append(loop, b.ID)
+				reported[b.ID] = true
+			}
+		}
+		issues = append(issues, lintIssue{File: a.Name, Rule: "supersession-cycle", Message: fmt.Sprintf("records %s supersede each other in a loop", strings.Join(loop, ", "))})
+	}
+	return issues
+}
+
+// fixSupersession completes the supersessions recorded on one record only,
+// returning the names of the records it changed.
+func fixSupersession(service *records.Service) ([]string, error) {
+	var fixed []string
+	var errs []error
+	for _, a := range service.GetRecords() {
+		updated, err := service.SyncSupersession(a)
+		for _, r := range updated {
+			if !slices.Contains(fixed, r.Name) {
+				fixed = append(fixed, r.Name)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return fixed, errors.Join(errs...)
+}
+
+// lintLinks checks the links of a record: their relation must be declared,
+// their target must exist and, when it is among the records, link back with
+// the inverse relation.
+func lintLinks(a records.AdrData, byID map[string]records.AdrData, opts lintOptions) []lintIssue {
+	var issues []lintIssue
+	for _, name := range slices.Sorted(maps.Keys(a.Links)) {
+		relation, known := opts.relations.Lookup(name)
+		if !known {
+			issues = append(issues, lintIssue{File: a.Name, Rule: "unknown-relation", Message: fmt.Sprintf("relation %q is not declared", name)})
+		}
+		for _, ref := range a.Links[name].ToSlice() {
+			target, ok := byID[ref]
+			if !ok {
+				if opts.exists == nil || !opts.exists(ref) {
+					issues = append(issues, lintIssue{File: a.Name, Rule: "dangling-link", Message: fmt.Sprintf("%s link to %q: record does not exist", name, ref)})
+				}
+				continue
+			}
+			if known && !target.Links[relation.Inverse][a.ID] {
+				issues = append(issues, lintIssue{File: a.Name, Rule: "missing-inverse", Message: fmt.Sprintf("%s link to %q: %s has no %s link back", name, ref, target.Name, relation.Inverse)})
+			}
+		}
+	}
+	return issues
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"testing"
 
+	cs "github.com/gwleclerc/adr/constants"
+
 	"github.com/gwleclerc/adr/records"
 )
 
@@ -22,11 +24,13 @@ func hasRule(issues []lintIssue, rule string) bool {
 }
 
 func TestLintRecordsClean(t *testing.T) {
+	a := mkFull("001_a.md", "a", "A", records.ACCEPTED)
+	a.Supersedes.Append("b")
 	clean := []records.AdrData{
-		mkFull("001_a.md", "a", "A", records.ACCEPTED),
+		a,
 		mkFull("002_b.md", "b", "B", records.SUPERSEDED, "a"), // superseded by an existing record
 	}
-	if issues := lintRecords(clean); len(issues) != 0 {
+	if issues := lintRecords(clean, lintOptions{workflow: records.DefaultWorkflow()}); len(issues) != 0 {
 		t.Errorf("expected no issues, got %+v", issues)
 	}
 }
@@ -50,6 +54,40 @@ func TestLintRecordsProblems(t *testing.T) {
 			},
 			"inconsistent-status",
 		},
+		{
+			"superseded status without superseders",
+			[]records.AdrData{mkFull("001_a.md", "a", "A", records.SUPERSEDED)},
+			"missing-superseders",
+		},
+		{
+			"superseder without supersedes",
+			[]records.AdrData{
+				mkFull("001_a.md", "a", "A", records.ACCEPTED),
+				mkFull("002_b.md", "b", "B", records.SUPERSEDED, "a"),
+			},
+			"asymmetric-supersession",
+		},
+		{
+			"supersedes without superseder",
+			[]records.AdrData{
+				{Name: "001_a.md", ID: "a", Title: "A", Status: records.ACCEPTED, Supersedes: records.Set[string]{"b": true}},
+				mkFull("002_b.md", "b", "B", records.ACCEPTED),
+			},
+			"asymmetric-supersession",
+		},
+		{
+			"dangling supersedes",
+			[]records.AdrData{{Name: "001_a.md", ID: "a", Title: "A", Status: records.ACCEPTED, Supersedes: records.Set[string]{"ghost": true}}},
+			"dangling-supersedes",
+		},
+		{
+			"supersession cycle",
+			[]records.AdrData{
+				mkFull("001_a.md", "a", "A", records.SUPERSEDED, "b"),
+				mkFull("002_b.md", "b", "B", records.SUPERSEDED, "a"),
+			},
+			"supersession-cycle",
+		},
 		{
 			"duplicate number",
 			[]records.AdrData{
@@ -71,10 +109,126 @@ func TestLintRecordsProblems(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			issues := lintRecords(tt.adrs)
+			issues := lintRecords(tt.adrs, lintOptions{workflow: records.DefaultWorkflow()})
 			if !hasRule(issues, tt.rule) {
 				t.Errorf("expected rule %q, got %+v", tt.rule, issues)
 			}
 		})
 	}
 }
+
+func TestLintRecordsFields(t *testing.T) {
+	fields := []cs.FieldSpec{
+		{Name: "jira", Type: records.FieldString, Required: true},
+		{Name: "risk", Type: records.FieldEnum, Values: []string{"low", "high"}},
+	}
+	missing := mkFull("001_a.md", "a", "A", records.ACCEPTED)
+	invalid := mkFull("002_b.md", "b", "B", records.ACCEPTED)
+	if err := invalid.Extra.Set("jira", "ARCH-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := invalid.Extra.Set("risk", "medium"); err != nil {
+		t.Fatal(err)
+	}
+
+	issues := lintRecords([]records.AdrData{missing, invalid}, lintOptions{workflow: records.DefaultWorkflow(), fields: fields})
+	if !hasRule(issues, "missing-field") || !hasRule(issues, "invalid-field") {
+		t.Errorf("expected missing-field and invalid-field, got %+v", issues)
+	}
+	if len(issues) != 2 {
+		t.Errorf("expected exactly 2 issues, got %+v", issues)
+	}
+}
+
+func TestLintRecordsWorkflow(t *testing.T) {
+	workflow, err := records.NewWorkflow([]cs.StatusSpec{{Name: "approved"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adrs := []records.AdrData{mkFull("001_a.md", "a", "A", records.AdrStatus("approved"))}
+	if issues := lintRecords(adrs, lintOptions{workflow: workflow}); len(issues) != 0 {
+		t.Errorf("a status the workflow declares is valid, got %+v", issues)
+	}
+	if !hasRule(lintRecords(adrs, lintOptions{workflow: records.DefaultWorkflow()}), "invalid-status") {
+		t.Error("expected invalid-status for a status the workflow does not declare")
+	}
+}
+
+func TestLintRecordsCategoryNumbering(t *testing.T) {
+	a := mkFull("backend/001_a.md", "a", "A", records.ACCEPTED)
+	a.Category = "backend"
+	b := mkFull("frontend/001_b.md", "b", "B", records.ACCEPTED)
+	b.Category = "frontend"
+	adrs := []records.AdrData{a, b}
+
+	if !hasRule(lintRecords(adrs, lintOptions{workflow: records.DefaultWorkflow()}), "duplicate-number") {
+		t.Error("with global numbering, categories share the numbers")
+	}
+	if issues := lintRecords(adrs, lintOptions{workflow: records.DefaultWorkflow(), perCategory: true}); len(issues) != 0 {
+		t.Errorf("with per-category numbering, expected no issues, got %+v", issues)
+	}
+}
+
+func TestLintRecordsCrossCollection(t *testing.T) {
+	adrs := []records.AdrData{
+		mkFull("001_a.md", "a", "A", records.SUPERSEDED, "payments:x"),
+		mkFull("002_b.md", "b", "B", records.SUPERSEDED, "payments:ghost"),
+	}
+	exists := func(ref string) bool { return ref == "payments:x" }
+	issues := lintRecords(adrs, lintOptions{workflow: records.DefaultWorkflow(), exists: exists})
+	if len(issues) != 1 || issues[0].File != "002_b.md" || issues[0].Rule != "dangling-superseder" {
+		t.Errorf("expected a single dangling superseder in 002_b.md, got %+v", issues)
+	}
+}
+
+func TestLintRecordsLinks(t *testing.T) {
+	a := mkFull("001_a.md", "a", "A", records.ACCEPTED)
+	b := mkFull("002_b.md", "b", "B", records.ACCEPTED)
+	c := mkFull("003_c.md", "c", "C", records.ACCEPTED)
+	a.AddLink("amends", "b")
+	b.AddLink("amended-by", "a")
+	a.AddLink("relates-to", "c") // c does not link back
+	b.AddLink("blocks", "a")
+	c.AddLink("depends-on", "ghost")
+
+	issues := lintRecords([]records.AdrData{a, b, c}, lintOptions{workflow: records.DefaultWorkflow(), relations: records.DefaultRelations()})
+	want := map[string]string{"001_a.md": "missing-inverse", "002_b.md": "unknown-relation", "003_c.md": "dangling-link"}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %+v", len(want), issues)
+	}
+	for _, is := range issues {
+		if want[is.File] != is.Rule {
+			t.Errorf("%s: rule %q, want %q", is.File, is.Rule, want[is.File])
+		}
+	}
+}
+
+func TestLintRecordsReferences(t *testing.T) {
+	a := mkFull("001_a.md", "a", "A", records.ACCEPTED)
+	a.Body = "See [[ADR-2]].\nAnd [[ghost]]."
+	b := mkFull("002_b.md", "b", "B", records.ACCEPTED)
+
+	issues := lintRecords([]records.AdrData{a, b}, lintOptions{
+		workflow:   records.DefaultWorkflow(),
+		references: func(r records.AdrData) []records.Reference { return records.References(r.Body) },
+		resolveReference: func(ref string) error {
+			if ref == "ADR-2" {
+				return nil
+			}
+			return &records.NotFoundError{Ref: ref}
+		},
+	})
+	if len(issues) != 1 || issues[0].Rule != "unknown-reference" || issues[0].File != "001_a.md" || issues[0].Line != 2 {
+		t.Errorf("expected an unknown-reference issue on 001_a.md:2, got %+v", issues)
+	}
+}
+
+func TestDiagnosticIssues(t *testing.T) {
+	issues := diagnosticIssues([]records.Diagnostic{
+		{File: "003_c.md", Line: 4, Kind: records.DiagnosticBadDate, Message: "invalid creation date"},
+	})
+	want := lintIssue{File: "003_c.md", Line: 4, Rule: "bad-date", Message: "invalid creation date"}
+	if len(issues) != 1 || issues[0] != want {
+		t.Errorf("diagnosticIssues() = %+v, want %+v", issues, want)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
+	"strings"
+	"time"
 
 	cs "github.com/gwleclerc/adr/constants"
 	"github.com/gwleclerc/adr/records"
@@ -16,10 +19,18 @@ func listCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "list",
 		Usage: "List ADR files",
-		Description: fmt.Sprintf(
-			"List ADR files present in directory stored in %s configuration file.",
+		Description: fmt.Sprintf(`List ADR files present in directory stored in %s configuration file.
+
+With --as-of, list the decisions as they stood on a date: records created later are
+left out, and statuses and superseders are reconstructed from each record's history.
+
+With --recursive, list the records of every project found below the current
+directory (e.g. the services of a monorepo), with the project of each record.`,
 			cs.ConfigurationFile,
 		),
+		// Flag values are split by splitCSV, so "--field name=a,b" reaches the
+		// action whole.
+		DisableSliceFlagSeparator: true,
 		Flags: []cli.Flag{
 			&cli.StringSliceFlag{
 				Name:    "authors",
@@ -36,45 +47,222 @@ func listCommand() *cli.Command {
 				Aliases: []string{"t"},
 				Usage:   "filter records by tags",
 			},
+			&cli.StringSliceFlag{
+				Name:    "category",
+				Aliases: []string{"c"},
+				Usage:   "filter records by category (including its subcategories)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "field",
+				Usage: "filter records by custom field, as name=value",
+			},
+			&cli.BoolFlag{
+				Name:  "drafts",
+				Usage: "only list drafts",
+			},
+			&cli.StringFlag{
+				Name:  "as-of",
+				Usage: "show the records as they stood on a date (YYYY-MM-DD, or an RFC 3339 time)",
+			},
+			&cli.BoolFlag{
+				Name:  "all-collections",
+				Usage: "list the records of every collection declared in " + cs.ConfigurationFile,
+			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"R"},
+				Usage:   "list the records of every project (" + cs.ConfigurationFile + " file) below the current directory",
+			},
 			&cli.BoolFlag{
 				Name:  "json",
 				Usage: "output records as JSON instead of a table",
 			},
 		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
-			service, err := records.NewService()
+			services, diagnostics, err := listServices(cmd)
+			if err != nil {
+				printError("%v", err)
+				return errSilent
+			}
+			specs := mergeFields(services)
+			fields, err := parseFieldFilters(specs, cmd.StringSlice("field"))
 			if err != nil {
-				printError("unable to initialize records service: %v", err)
+				printError("invalid field filter: %v", err)
 				return errSilent
 			}
 			filters := listFilters{
-				authors: splitCSV(cmd.StringSlice("authors")),
-				status:  splitCSV(cmd.StringSlice("status")),
-				tags:    splitCSV(cmd.StringSlice("tags")),
+				authors:    splitCSV(cmd.StringSlice("authors")),
+				status:     splitCSV(cmd.StringSlice("status")),
+				tags:       splitCSV(cmd.StringSlice("tags")),
+				categories: splitCSV(cmd.StringSlice("category")),
+				fields:     fields,
+				drafts:     cmd.Bool("drafts"),
 			}
-			adrs := filterRecords(service.GetRecords(), filters)
+			get := (*records.Service).GetRecords
+			if cmd.IsSet("as-of") {
+				at, err := parseAsOf(cmd.String("as-of"))
+				if err != nil {
+					printError("invalid date: %v", err)
+					return errSilent
+				}
+				get = func(s *records.Service) []records.AdrData { return s.GetRecordsAsOf(at) }
+			}
+			var all []records.AdrData
+			for _, s := range services {
+				all = append(all, get(s)...)
+			}
+			adrs := filterRecords(all, filters)
 			if cmd.Bool("json") {
-				if err := printJSON(adrs); err != nil {
+				if diagnostics == nil {
+					diagnostics = []records.Diagnostic{}
+				}
+				if err := printJSON(listOutput{Records: adrs, Errors: diagnostics}); err != nil {
 					printError("unable to encode records: %v", err)
 					return errSilent
 				}
 				return nil
 			}
-			renderTable(adrs)
+			warnDiagnostics(diagnostics)
+			renderTable(adrs, specs, serviceWorkflows(services))
 			return nil
 		},
 	}
 }
 
+// listOutput is the JSON output of list: the records, and the files that could
+// not be parsed.
+type listOutput struct {
+	Records []records.AdrData    `json:"records"`
+	Errors  []records.Diagnostic `json:"errors"`
+}
+
+// listServices returns the services whose records are listed: the current
+// project's (or every collection of it with --all-collections), or with
+// --recursive every project found below the current directory. It also returns
+// the files that could not be parsed.
+func listServices(cmd *cli.Command) ([]*records.Service, []records.Diagnostic, error) {
+	if cmd.Bool("recursive") {
+		return discoverProjects(cmd, records.WithMetadataOnly())
+	}
+	service, err := loadService(cmd, records.WithMetadataOnly())
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to initialize records service: %w", err)
+	}
+	services := []*records.Service{service}
+	if cmd.Bool("all-collections") {
+		if services, err = openCollections(service); err != nil {
+			return nil, nil, fmt.Errorf("unable to open collection: %w", err)
+		}
+	}
+	var diagnostics []records.Diagnostic
+	for _, s := range services {
+		diagnostics = append(diagnostics, s.Diagnostics()...)
+	}
+	return services, diagnostics, nil
+}
+
+// discoverProjects returns a service per ADR log of the projects found below
+// the current directory, along with the files that could not be parsed.
+func discoverProjects(cmd *cli.Command, opts ...records.Option) ([]*records.Service, []records.Diagnostic, error) {
+	services, diagnostics, err := records.DiscoverProjects(".", serviceOptions(cmd, opts...)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to discover projects: %w", err)
+	}
+	if len(services) == 0 && len(diagnostics) == 0 {
+		return nil, nil, fmt.Errorf("no %s file found below the current directory", cs.ConfigurationFile)
+	}
+	for _, s := range services {
+		diagnostics = append(diagnostics, s.Diagnostics()...)
+	}
+	return services, diagnostics, nil
+}
+
+// mergeFields returns the custom fields declared by the services, the first
+// declaration of a name winning.
+func mergeFields(services []*records.Service) []cs.FieldSpec {
+	var specs []cs.FieldSpec
+	for _, s := range services {
+		for _, spec := range s.Fields() {
+			if !slices.ContainsFunc(specs, func(f cs.FieldSpec) bool { return f.Name == spec.Name }) {
+				specs = append(specs, spec)
+			}
+		}
+	}
+	return specs
+}
+
+// openCollections returns a service per collection of the project, or the
+// given one when the project has no collections.
+func openCollections(service *records.Service) ([]*records.Service, error) {
+	names := service.Collections()
+	if len(names) == 0 {
+		return []*records.Service{service}, nil
+	}
+	services := make([]*records.Service, 0, len(names))
+	for _, name := range names {
+		s, err := service.OpenCollection(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		services = append(services, s)
+	}
+	return services, nil
+}
+
+// serviceWorkflows returns the workflow of the project and collection each
+// record listed by services comes from.
+func serviceWorkflows(services []*records.Service) func(records.AdrData) records.Workflow {
+	workflows := make(map[[2]string]records.Workflow, len(services))
+	for _, s := range services {
+		workflows[[2]string{s.Project(), s.Collection()}] = s.Workflow()
+	}
+	return func(adr records.AdrData) records.Workflow {
+		if w, ok := workflows[[2]string{adr.Project, adr.Collection}]; ok {
+			return w
+		}
+		return records.DefaultWorkflow()
+	}
+}
+
+// collectionWorkflows returns the workflow of the collection of each record, as
+// opened from service: the service's own when it cannot be opened.
+func collectionWorkflows(service *records.Service) func(records.AdrData) records.Workflow {
+	return func(adr records.AdrData) records.Workflow {
+		if owner, err := service.For(adr); err == nil {
+			return owner.Workflow()
+		}
+		return service.Workflow()
+	}
+}
+
+// parseAsOf parses a point in time. A bare date stands for the end of that day,
+// so records created or changed on it are included.
+func parseAsOf(v string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (expected YYYY-MM-DD or an RFC 3339 time)", v)
+	}
+	return t, nil
+}
+
 type listFilters struct {
-	authors []string
-	status  []string
-	tags    []string
+	authors    []string
+	status     []string
+	tags       []string
+	categories []string
+	fields     map[string][]string
+	drafts     bool
 }
 
 func filterRecords(adrs []records.AdrData, filters listFilters) []records.AdrData {
 	out := make([]records.AdrData, 0, len(adrs))
 	for _, adr := range adrs {
+		if filters.drafts && !adr.Draft {
+			continue
+		}
 		if len(filters.authors) > 0 && !slices.Contains(filters.authors, adr.Author) {
 			continue
 		}
@@ -93,18 +281,98 @@ func filterRecords(adrs []records.AdrData, filters listFilters) []records.AdrDat
 				continue
 			}
 		}
+		if len(filters.categories) > 0 && !slices.ContainsFunc(filters.categories, func(c string) bool { return inCategory(adr, c) }) {
+			continue
+		}
+		if !matchesFields(adr, filters.fields) {
+			continue
+		}
 		out = append(out, adr)
 	}
 	return out
 }
 
-func renderTable(adrs []records.AdrData) {
+// inCategory reports whether a record is in a category or one of its
+// subcategories.
+func inCategory(adr records.AdrData, category string) bool {
+	category = strings.Trim(filepath.ToSlash(category), "/")
+	return adr.Category == category || strings.HasPrefix(adr.Category, category+"/")
+}
+
+// matchesFields reports whether a record matches every custom field filter.
+func matchesFields(adr records.AdrData, fields map[string][]string) bool {
+	for name, wanted := range fields {
+		if !fieldMatches(adr, name, wanted) {
+			return false
+		}
+	}
+	return true
+}
+
+// renderTable prints the records as a table, with a column per custom field. When
+// records come from several projects or collections, their project and collection
+// are shown in columns.
+// When they are organized in categories, they are grouped by category, shown in
+// a column of its own. Statuses are colored as in the workflow of each record.
+func renderTable(adrs []records.AdrData, fields []cs.FieldSpec, workflow func(records.AdrData) records.Workflow) {
+	categorized := slices.ContainsFunc(adrs, func(a records.AdrData) bool { return a.Category != "" })
+	collections := slices.ContainsFunc(adrs, func(a records.AdrData) bool { return a.Collection != adrs[0].Collection })
+	projects := slices.ContainsFunc(adrs, func(a records.AdrData) bool { return a.Project != adrs[0].Project })
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader(cs.TableHeader)
+	header := slices.Clone(cs.TableHeader)
+	if projects {
+		header = append(header, "Project")
+	}
+	if collections {
+		header = append(header, "Collection")
+	}
+	if categorized {
+		header = append(header, "Category")
+		var grouped []records.AdrData
+		for _, log := range groupByLog(adrs) {
+			for _, group := range groupByCategory(log) {
+				grouped = append(grouped, group.adrs...)
+			}
+		}
+		adrs = grouped
+	}
+	for _, spec := range fields {
+		header = append(header, spec.Name)
+	}
+	table.SetHeader(header)
 	for _, adr := range adrs {
-		table.Append(adr.ToRow())
+		row := adr.ToRow(workflow(adr))
+		if projects {
+			row = append(row, adr.Project)
+		}
+		if collections {
+			row = append(row, adr.Collection)
+		}
+		if categorized {
+			row = append(row, adr.Category)
+		}
+		for _, spec := range fields {
+			v, _ := adr.Extra.Get(spec.Name)
+			row = append(row, records.FormatFieldValue(v))
+		}
+		table.Append(row)
 	}
 	fmt.Println()
 	table.Render()
 	fmt.Println()
 }
+
+// groupByLog splits records by ADR log (project and collection), which come one
+// after the other.
+func groupByLog(adrs []records.AdrData) [][]records.AdrData {
+	var logs [][]records.AdrData
+	for start := 0; start < len(adrs); {
+		end := start + 1
+		for end < len(adrs) && adrs[end].Project == adrs[start].Project && adrs[end].Collection == adrs[start].Collection {
+			end++
+		}
+		logs = append(logs, adrs[start:end])
+		start = end
+	}
+	return logs
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gwleclerc/adr/records"
 )
@@ -19,6 +20,9 @@ func TestFilterRecords(t *testing.T) {
 		mkRecord("2", "bob", records.DEPRECATED, "db"),
 		mkRecord("3", "alice", records.ACCEPTED, "db", "api"),
 	}
+	if err := adrs[2].Extra.Set("deciders", []string{"alice", "bob"}); err != nil {
+		t.Fatal(err)
+	}
 
 	ids := func(rs []records.AdrData) []string {
 		out := make([]string, 0, len(rs))
@@ -39,6 +43,7 @@ func TestFilterRecords(t *testing.T) {
 		{"by tag", listFilters{tags: []string{"db"}}, []string{"2", "3"}},
 		{"author AND tag", listFilters{authors: []string{"alice"}, tags: []string{"db"}}, []string{"3"}},
 		{"no match", listFilters{authors: []string{"carol"}}, []string{}},
+		{"by field", listFilters{fields: map[string][]string{"deciders": {"bob"}}}, []string{"3"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -49,3 +54,19 @@ func TestFilterRecords(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAsOf(t *testing.T) {
+	got, err := parseAsOf("2025-06-01")
+	if err != nil {
+		t.Fatalf("parseAsOf: %v", err)
+	}
+	if want := time.Date(2025, 6, 1, 23, 59, 59, 999999999, time.Local); !got.Equal(want) {
+		t.Errorf("a bare date should stand for the end of the day, got %s", got)
+	}
+	if got, err := parseAsOf("2025-06-01T10:00:00Z"); err != nil || !got.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseAsOf(RFC 3339) = %s, %v", got, err)
+	}
+	if _, err := parseAsOf("June 1st"); err == nil {
+		t.Error("an invalid date should be rejected")
+	}
+}
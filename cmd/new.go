@@ -20,13 +20,16 @@ type newRecordOptions struct {
 	author     string
 	status     records.AdrStatus
 	tags       []string
+	category   string
 	supersedes []string
+	fields     map[string]any
 	body       string
+	draft      bool
 	edit       bool
 	json       bool
 }
 
-func newCommand() *cli.Command {
+func newCommand(workflow records.Workflow) *cli.Command {
 	return &cli.Command{
 		Name:      "new",
 		Usage:     "Create a new ADR",
@@ -34,7 +37,10 @@ func newCommand() *cli.Command {
 		Description: fmt.Sprintf(`Create a new architecture decision record.
 It will be created in the directory defined in the nearest %s configuration file.
 
-%s`, cs.ConfigurationFile, records.StatusHelp()),
+%s`, cs.ConfigurationFile, workflow.StatusHelp()),
+		// Flag values are split by splitCSV, so a list field value (--field a=x,y)
+		// reaches the action whole.
+		DisableSliceFlagSeparator: true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "author",
@@ -45,18 +51,27 @@ It will be created in the directory defined in the nearest %s configuration file
 				Name:    "status",
 				Aliases: []string{"s"},
 				Value:   string(records.ACCEPTED),
-				Usage:   "status of the record, allowed: " + records.AllowedStatuses(),
+				Usage:   "status of the record, allowed: " + workflow.AllowedStatuses(),
 			},
 			&cli.StringSliceFlag{
 				Name:    "tags",
 				Aliases: []string{"t"},
 				Usage:   "tags of the record",
 			},
+			&cli.StringFlag{
+				Name:    "category",
+				Aliases: []string{"c"},
+				Usage:   "subdirectory of the ADR directory to create the record in (e.g. backend)",
+			},
 			&cli.StringSliceFlag{
 				Name:    "supersedes",
 				Aliases: []string{"r"},
 				Usage:   "record ids superseded by this one",
 			},
+			&cli.StringSliceFlag{
+				Name:  "field",
+				Usage: "custom field declared in " + cs.ConfigurationFile + ", as name=value (lists are comma-separated)",
+			},
 			&cli.StringFlag{
 				Name:  "template",
 				Value: "bare",
@@ -66,6 +81,10 @@ It will be created in the directory defined in the nearest %s configuration file
 				Name:  "body-file",
 				Usage: "read the record body from a file (or - for stdin) instead of the template; validated against --template",
 			},
+			&cli.BoolFlag{
+				Name:  "draft",
+				Usage: "create an unnumbered draft, numbered later by `adr promote` (status defaults to proposed)",
+			},
 			&cli.BoolFlag{
 				Name:  "edit",
 				Usage: "open the created record in $EDITOR",
@@ -81,16 +100,21 @@ It will be created in the directory defined in the nearest %s configuration file
 				missingArgument("title")
 				return errSilent
 			}
-			status, err := records.ParseStatus(cmd.String("status"))
+			service, err := newService(cmd)
 			if err != nil {
-				printError("invalid status: %v", err)
+				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
-			service, err := records.NewService()
+			rawStatus := cmd.String("status")
+			if cmd.Bool("draft") && !cmd.IsSet("status") {
+				rawStatus = string(records.PROPOSED)
+			}
+			status, err := service.ParseStatus(rawStatus)
 			if err != nil {
-				printError("unable to initialize records service: %v", err)
+				printError("invalid status: %v", err)
 				return errSilent
 			}
+			service.SetActor(resolveActor())
 
 			reg, err := templates.Load(service.TemplatesDir())
 			if err != nil {
@@ -120,6 +144,16 @@ It will be created in the directory defined in the nearest %s configuration file
 				body = content
 			}
 
+			fields, err := parseFieldFlags(service.Fields(), cmd.StringSlice("field"))
+			if err != nil {
+				printError("invalid field: %v", err)
+				return errSilent
+			}
+			if err := records.CompleteFields(service.Fields(), fields); err != nil {
+				printError("invalid fields: %v", err)
+				return errSilent
+			}
+
 			author := cmd.String("author")
 			if author == "" {
 				author = service.DefaultAuthor()
@@ -128,8 +162,11 @@ It will be created in the directory defined in the nearest %s configuration file
 				author:     author,
 				status:     status,
 				tags:       splitCSV(cmd.StringSlice("tags")),
+				category:   cmd.String("category"),
 				supersedes: splitCSV(cmd.StringSlice("supersedes")),
+				fields:     fields,
 				body:       body,
+				draft:      cmd.Bool("draft"),
 				edit:       cmd.Bool("edit"),
 				json:       cmd.Bool("json"),
 			}
@@ -166,19 +203,25 @@ func newRecord(service *records.Service, title string, opts newRecordOptions) er
 	}
 
 	record := records.AdrData{
-		ID:     id,
-		Status: opts.status,
-		Author: author,
-		Tags:   make(records.Set[string]),
+		ID:       id,
+		Status:   opts.status,
+		Author:   author,
+		Tags:     make(records.Set[string]),
+		Category: opts.category,
+		Draft:    opts.draft,
 	}
 	record.Tags.Append(opts.tags...)
+	record.Supersedes.Append(resolveSuperseded(service, opts.supersedes)...)
+	if err := applyFields(&record, service.Fields(), opts.fields); err != nil {
+		return err
+	}
 
 	created, err := service.CreateRecord(title, record, opts.body)
 	if err != nil {
 		return err
 	}
 
-	markSuperseded(service, created.ID, opts.supersedes)
+	markSuperseded(service, created)
 
 	if opts.json {
 		if err := printJSON(created); err != nil {
@@ -186,7 +229,11 @@ func newRecord(service *records.Service, title string, opts newRecordOptions) er
 		}
 	} else {
 		fmt.Println()
-		fmt.Println(cs.Green("Record has been successfully created with ID %q", created.ID))
+		kind := "Record"
+		if created.Draft {
+			kind = "Draft"
+		}
+		fmt.Println(cs.Green("%s has been successfully created with ID %q", kind, created.ID))
 		fmt.Println()
 	}
 
@@ -196,6 +243,18 @@ func newRecord(service *records.Service, title string, opts newRecordOptions) er
 	return nil
 }
 
+// resolveActor names who makes a change, for the status history: the git user,
+// else the OS user. Unlike resolveAuthor it stays silent and may return "".
+func resolveActor() string {
+	if username, err := gitconfig.Username(); err == nil {
+		return username
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
 // resolveAuthor determines the record author from the git config, falling back
 // to the OS user and finally to the default user name.
 func resolveAuthor() string {
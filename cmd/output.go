@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
@@ -21,8 +22,9 @@ func printJSON(v any) error {
 }
 
 // reportRecord prints a just-updated record, as JSON when jsonOut is set,
-// otherwise as a confirmation message followed by a one-row table.
-func reportRecord(record records.AdrData, jsonOut bool) error {
+// otherwise as a confirmation message followed by a one-row table, its status
+// colored as in workflow.
+func reportRecord(record records.AdrData, workflow records.Workflow, jsonOut bool) error {
 	if jsonOut {
 		return printJSON(record)
 	}
@@ -30,8 +32,20 @@ func reportRecord(record records.AdrData, jsonOut bool) error {
 	fmt.Println(cs.Green("Record %q has been successfully updated:", record.ID))
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader(cs.TableHeader)
-	table.Append(record.ToRow())
+	table.Append(record.ToRow(workflow))
 	table.Render()
 	fmt.Println()
 	return nil
 }
+
+// printUpdateError reports a failed record update, pointing at --force when the
+// record was edited on disk after it was read or the status change is not allowed.
+func printUpdateError(recordID string, err error) {
+	printError("unable to update ADR %q: %v", recordID, err)
+	switch {
+	case errors.Is(err, records.ErrConflict):
+		printWarning("re-run with --force to overwrite the changes made on disk")
+	case errors.Is(err, records.ErrTransition):
+		printWarning("re-run with --force to bypass the status workflow")
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/urfave/cli/v3"
+)
+
+func promoteCommand(workflow records.Workflow) *cli.Command {
+	return &cli.Command{
+		Name:      "promote",
+		Usage:     "Number a draft and move it into the ADR directory",
+		ArgsUsage: "<draft ID>",
+		Description: `Turn a draft (created with "adr new --draft") into a numbered record once the
+proposal is accepted: it takes the next free number, moves into the ADR directory and gets
+the --status given (accepted by default). Links to the draft in the ADR directory are rewritten.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "status",
+				Aliases: []string{"s"},
+				Value:   string(records.ACCEPTED),
+				Usage:   "status of the promoted record, allowed: " + workflow.AllowedStatuses(),
+			},
+			&cli.StringFlag{Name: "reason", Usage: "reason for the status change, recorded in the history"},
+			&cli.BoolFlag{Name: "force", Usage: "overwrite the draft even if it changed on disk, and allow any status change"},
+			&cli.BoolFlag{Name: "json", Usage: "print the promoted record as JSON"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() == 0 {
+				missingArgument("draft ID")
+				return errSilent
+			}
+			service, err := newService(cmd)
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
+			status, err := service.ParseStatus(cmd.String("status"))
+			if err != nil {
+				printError("invalid status: %v", err)
+				return errSilent
+			}
+			applyChangeFlags(service, cmd)
+			record, ok := resolveRecord(service, cmd.Args().First())
+			if !ok {
+				return errSilent
+			}
+			promoted, changed, err := service.PromoteRecord(record, status)
+			if err != nil {
+				printUpdateError(record.ID, err)
+				return errSilent
+			}
+			refreshTOC(service)
+			if !cmd.Bool("json") {
+				fmt.Println(cs.Green("Draft promoted to %s", promoted.Name))
+				if len(changed) > 0 {
+					fmt.Println(cs.Green("Links updated in %s", strings.Join(changed, ", ")))
+				}
+			}
+			if err := reportRecord(promoted, service.Workflow(), cmd.Bool("json")); err != nil {
+				printError("unable to encode record: %v", err)
+				return errSilent
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
+	"github.com/urfave/cli/v3"
+)
+
+func renumberCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "renumber",
+		Usage: "Give every ADR its own number",
+		Description: `Resolve duplicate record numbers, e.g. after merging two branches that each created
+the same number: the oldest record (by creation date) keeps the number, the others take the next
+free ones. With --gaps, records are also renumbered from 1 without holes. Files are renamed (IDs
+do not change), the links to them are rewritten across the ADR directory, and so are the
+references bodies make to them by number ("[[ADR-004]]").`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "gaps",
+				Usage: "also close the gaps in the numbering",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only show the planned renames",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "print the renames as JSON",
+			},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			service, err := newService(cmd)
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
+			moves := service.PlanRenumber(cmd.Bool("gaps"))
+			if moves == nil {
+				moves = []records.Move{}
+			}
+			var changed []string
+			if !cmd.Bool("dry-run") {
+				changed, err = service.Renumber(moves)
+				if err != nil {
+					printError("unable to renumber records: %v", err)
+					return errSilent
+				}
+				if len(moves) > 0 {
+					refreshTOC(service)
+				}
+			}
+
+			if cmd.Bool("json") {
+				if err := printJSON(moves); err != nil {
+					printError("unable to encode renames: %v", err)
+					return errSilent
+				}
+				return nil
+			}
+			if len(moves) == 0 {
+				fmt.Println(cs.Green("Nothing to renumber."))
+				return nil
+			}
+			verb := "Renamed"
+			if cmd.Bool("dry-run") {
+				verb = "Would rename"
+			}
+			for _, m := range moves {
+				fmt.Printf("%s %s -> %s\n", verb, m.From, m.To)
+			}
+			if len(changed) > 0 {
+				fmt.Println(cs.Green("Links updated in %s", strings.Join(changed, ", ")))
+			}
+			return nil
+		},
+	}
+}
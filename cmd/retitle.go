@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+	"github.com/urfave/cli/v3"
+)
+
+func retitleCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "retitle",
+		Usage:     "Change the title of an ADR",
+		ArgsUsage: "<record ID> <new title...>",
+		Description: `Change the title of an existing architecture decision record.
+It updates the title in the metadata and the heading of the record, renames the file
+(keeping its number), and rewrites the links to the old filename in the other files of
+the ADR directory and in the table of contents configured with "toc".`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "overwrite the record even if it changed on disk since it was read",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "print the updated record as JSON",
+			},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() < 2 {
+				printError("retitle requires <record ID> and <new title>")
+				return errSilent
+			}
+			title := strings.TrimSpace(strings.Join(cmd.Args().Slice()[1:], " "))
+			service, err := newService(cmd)
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
+			service.SetForce(cmd.Bool("force"))
+			record, ok := resolveRecord(service, cmd.Args().First())
+			if !ok {
+				return errSilent
+			}
+			updated, changed, err := service.RetitleRecord(record, title)
+			if err != nil {
+				printUpdateError(record.ID, err)
+				return errSilent
+			}
+			refreshTOC(service)
+			if !cmd.Bool("json") && len(changed) > 0 {
+				fmt.Println(cs.Green("Links updated in %s", strings.Join(changed, ", ")))
+			}
+			if err := reportRecord(updated, service.Workflow(), cmd.Bool("json")); err != nil {
+				printError("unable to encode record: %v", err)
+				return errSilent
+			}
+			return nil
+		},
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	cs "github.com/gwleclerc/adr/constants"
+	"github.com/gwleclerc/adr/records"
 	"github.com/urfave/cli/v3"
 )
 
@@ -28,6 +29,7 @@ type BuildInfo struct {
 
 // newApp wires the root command and its subcommands.
 func newApp(bi BuildInfo) *cli.Command {
+	workflow := projectWorkflow()
 	cli.VersionPrinter = func(cmd *cli.Command) {
 		fmt.Printf("%s version %s\ncommit: %s\nbuilt at: %s\n", bi.AppName, bi.Version, bi.Commit, bi.Date)
 	}
@@ -38,19 +40,42 @@ func newApp(bi BuildInfo) *cli.Command {
 		Version:               bi.Version,
 		HideHelpCommand:       true,
 		EnableShellCompletion: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "collection",
+				Usage:   "collection of records to work on, among the ones declared in " + cs.ConfigurationFile,
+				Sources: cli.EnvVars("ADR_COLLECTION"),
+			},
+			&cli.BoolFlag{
+				Name:    "no-cache",
+				Usage:   "parse every record file, bypassing the index cache (.adr/cache)",
+				Sources: cli.EnvVars("ADR_NO_CACHE"),
+			},
+		},
 		Commands: []*cli.Command{
 			initCommand(),
-			newCommand(),
+			newCommand(workflow),
+			promoteCommand(workflow),
 			addCommand(),
-			updateCommand(),
+			updateCommand(workflow),
 			deprecateCommand(),
 			supersedeCommand(),
+			lineageCommand(),
+			currentCommand(),
+			linkCommand(),
+			unlinkCommand(),
+			retitleCommand(),
+			renumberCommand(),
+			archiveCommand(),
+			deleteCommand(),
 			listCommand(),
 			showCommand(),
 			editCommand(),
 			tocCommand(),
+			graphCommand(),
 			lintCommand(),
 			templateCommand(),
+			cacheCommand(),
 		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() == 0 {
@@ -77,6 +102,63 @@ func Execute(bi BuildInfo) {
 	}
 }
 
+// projectWorkflow returns the workflow declared in the nearest configuration, if
+// any, for help texts and flag usages to list its statuses: the built-in one
+// otherwise. Commands validate statuses against the workflow of the collection
+// they work on.
+func projectWorkflow() records.Workflow {
+	cfg, _, err := records.LoadConfig()
+	if err != nil {
+		return records.DefaultWorkflow()
+	}
+	w, err := records.NewWorkflow(cfg.Statuses, cfg.Transitions)
+	if err != nil {
+		return records.DefaultWorkflow()
+	}
+	return w
+}
+
+// newService indexes the records of the collection selected with --collection
+// (the default one when unset), warning about the files that could not be parsed.
+func newService(cmd *cli.Command, opts ...records.Option) (*records.Service, error) {
+	service, err := loadService(cmd, opts...)
+	if err != nil {
+		return nil, err
+	}
+	warnDiagnostics(service.Diagnostics())
+	return service, nil
+}
+
+// loadService is newService for commands that report the files that could not
+// be parsed themselves.
+func loadService(cmd *cli.Command, opts ...records.Option) (*records.Service, error) {
+	return records.NewService(append(serviceOptions(cmd, opts...), records.WithCollection(cmd.String("collection")))...)
+}
+
+// warnDiagnostics prints the files left out of the index as warnings.
+func warnDiagnostics(diagnostics []records.Diagnostic) {
+	for _, d := range diagnostics {
+		printWarning("Skipping %s", d)
+	}
+}
+
+// serviceOptions returns the indexing options set by the global flags, after the
+// given ones.
+func serviceOptions(cmd *cli.Command, opts ...records.Option) []records.Option {
+	if cmd.Bool("no-cache") {
+		opts = append(opts, records.WithoutCache())
+	}
+	return opts
+}
+
+// applyChangeFlags applies the --force and --reason flags of a command that
+// modifies records, and records the current user as the author of the changes.
+func applyChangeFlags(service *records.Service, cmd *cli.Command) {
+	service.SetForce(cmd.Bool("force"))
+	service.SetReason(cmd.String("reason"))
+	service.SetActor(resolveActor())
+}
+
 // missingArgument prints the canonical "please specify a <what> in arguments" error.
 func missingArgument(what string) {
 	fmt.Fprintf(os.Stderr, "%s %s %s\n",
@@ -3,7 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/gwleclerc/adr/records"
 	"github.com/urfave/cli/v3"
@@ -11,46 +11,131 @@ import (
 
 func showCommand() *cli.Command {
 	return &cli.Command{
-		Name:      "show",
-		Usage:     "Print a single ADR",
+		Name:  "show",
+		Usage: "Print a single ADR",
+		Description: `Print a record file, followed by the timeline of its status changes, its links
+to other records, including the ones only the other records store (inferred), and the records
+whose body refers to it (backlinks).
+
+References to records written in the body as "[[ADR-004]]", "[[<ID>]]" or
+"[[ADR-004|some text]]" are printed as markdown links to the records, with their current
+title as text; --raw prints the file as it is.`,
 		ArgsUsage: "<record ID>",
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "json",
 				Usage: "print the record metadata as JSON instead of the file",
 			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "print the body without resolving its [[...]] references",
+			},
 		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
 			if cmd.Args().Len() == 0 {
 				missingArgument("record ID")
 				return errSilent
 			}
-			service, err := records.NewService()
+			service, err := newService(cmd, records.WithMetadataOnly())
 			if err != nil {
 				printError("unable to initialize records service: %v", err)
 				return errSilent
 			}
-			record, ok := service.GetRecord(cmd.Args().First())
+			record, ok := resolveRecord(service, cmd.Args().First())
 			if !ok {
-				printError("record %q not found", cmd.Args().First())
 				return errSilent
 			}
 
+			inferred := service.InferredLinks(record)
+			backlinks := service.Backlinks(record)
 			if cmd.Bool("json") {
-				if err := printJSON(record); err != nil {
+				out := showOutput{AdrData: record, InferredLinks: inferred}
+				for _, r := range backlinks {
+					out.Backlinks = append(out.Backlinks, r.ID)
+				}
+				if err := printJSON(out); err != nil {
 					printError("unable to encode record: %v", err)
 					return errSilent
 				}
 				return nil
 			}
 
-			b, err := os.ReadFile(service.RecordPath(record))
+			b, err := service.Content(record)
 			if err != nil {
 				printError("unable to read record: %v", err)
 				return errSilent
 			}
-			fmt.Print(string(b))
+			content := string(b)
+			if body, err := service.Body(record); err == nil && !cmd.Bool("raw") {
+				if head, tail, ok := splitBody(content, body); ok {
+					content = head + service.ExpandReferences(record, body) + tail
+				}
+			}
+			fmt.Print(content)
+			if len(record.History) > 0 {
+				fmt.Println()
+				fmt.Print(renderHistory(record.History, service.Workflow()))
+			}
+			if links := renderLinks(record.Links, inferred, describeRef(service)); links != "" {
+				fmt.Println()
+				fmt.Print(links)
+			}
+			if len(backlinks) > 0 {
+				fmt.Println()
+				fmt.Print(renderBacklinks(backlinks))
+			}
 			return nil
 		},
 	}
 }
+
+// showOutput is the JSON output of show: the record, with the links to it that
+// only the linking records store, by inverse relation name, and the IDs of the
+// records whose body refers to it.
+type showOutput struct {
+	records.AdrData
+	InferredLinks map[string][]string `json:"inferred_links,omitempty"`
+	Backlinks     []string            `json:"backlinks,omitempty"`
+}
+
+// splitBody returns what comes before and after the body of a record (as
+// returned by Body) in its file.
+func splitBody(content, body string) (head, tail string, ok bool) {
+	i := strings.LastIndex(content, body)
+	if i < 0 {
+		return "", "", false
+	}
+	return content[:i], content[i+len(body):], true
+}
+
+// renderBacklinks renders the records referring to a record in their body.
+func renderBacklinks(backlinks []records.AdrData) string {
+	var b strings.Builder
+	b.WriteString("Backlinks:\n")
+	for _, r := range backlinks {
+		fmt.Fprintf(&b, "  %s %s (%s)\n", r.ID, r.Title, r.Name)
+	}
+	return b.String()
+}
+
+// renderHistory renders a record's status changes as a timeline, oldest first,
+// statuses colored as in workflow.
+func renderHistory(history []records.HistoryEntry, workflow records.Workflow) string {
+	var b strings.Builder
+	b.WriteString("History:\n")
+	for _, h := range history {
+		date := "-"
+		if !h.Date.IsZero() {
+			date = h.Date.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(&b, "  %s  %s", date, workflow.Colorize(h.Status))
+		if h.Actor != "" {
+			fmt.Fprintf(&b, " by %s", h.Actor)
+		}
+		if h.Reason != "" {
+			fmt.Fprintf(&b, ": %s", h.Reason)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gwleclerc/adr/records"
+)
+
+func TestRenderHistory(t *testing.T) {
+	history := []records.HistoryEntry{
+		{Status: records.PROPOSED, Date: time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local), Actor: "alice"},
+		{Status: records.ACCEPTED, Date: time.Date(2026, 2, 3, 11, 30, 0, 0, time.Local), Actor: "bob", Reason: "approved at review"},
+		{Status: records.DEPRECATED}, // no date nor actor
+	}
+	out := renderHistory(history, records.DefaultWorkflow())
+	for _, want := range []string{
+		"History:",
+		"2026-01-02 10:00  proposed by alice\n",
+		"2026-02-03 11:30  accepted by bob: approved at review\n",
+		"  -  deprecated\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("timeline missing %q\n---\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "proposed") > strings.Index(out, "accepted") {
+		t.Errorf("timeline should be oldest first\n%s", out)
+	}
+}
+
+func TestRenderLinks(t *testing.T) {
+	if out := renderLinks(nil, nil, nil); out != "" {
+		t.Errorf("no links rendered %q, want nothing", out)
+	}
+	var record records.AdrData
+	record.AddLink("amends", "b")
+	record.AddLink("amends", "a")
+	out := renderLinks(record.Links, map[string][]string{"required-by": {"c"}}, func(ref string) string { return "<" + ref + ">" })
+	want := []string{"Links:", "  amends       <a>\n", "  amends       <b>\n", "  required-by  <c>"}
+	last := 0
+	for _, w := range want {
+		i := strings.Index(out, w)
+		if i < last {
+			t.Fatalf("links missing or out of order %q\n---\n%s", w, out)
+		}
+		last = i
+	}
+	if !strings.Contains(out, "(inferred)") {
+		t.Errorf("inferred link not marked\n%s", out)
+	}
+}
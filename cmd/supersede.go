@@ -1,29 +1,78 @@
 package cmd
 
-import "github.com/gwleclerc/adr/records"
+import (
+	"errors"
+	"fmt"
 
-// markSuperseded flags each target record as superseded by bySuperseder and
-// back-links it. Unknown target IDs produce a warning instead of being ignored.
-func markSuperseded(service *records.Service, bySuperseder string, targetIDs []string) {
-	for _, id := range targetIDs {
-		rcd, ok := service.GetRecord(id)
-		if !ok {
-			printWarning("superseded record %q not found, skipping", id)
-			continue
-		}
-		rcd.Status = records.SUPERSEDED
-		rcd.Superseders.Append(bySuperseder)
-		if err := service.UpdateRecord(rcd); err != nil {
-			printWarning("unable to update superseded record %q: %v", rcd.ID, err)
+	"github.com/gwleclerc/adr/records"
+)
+
+// resolveRecord finds the record a command argument refers to (an ID, number,
+// filename, ID prefix or title), printing why when it cannot. Records of other
+// collections are refused: the command must run with --collection.
+func resolveRecord(service *records.Service, ref string) (records.AdrData, bool) {
+	record, err := resolveLocal(service, ref)
+	if err != nil {
+		printError("%v", err)
+		return records.AdrData{}, false
+	}
+	return record, true
+}
+
+// resolveLocal resolves a reference to a record of the service's collection.
+func resolveLocal(service *records.Service, ref string) (records.AdrData, error) {
+	record, err := service.Resolve(ref)
+	if err == nil && record.Collection != service.Collection() {
+		return records.AdrData{}, fmt.Errorf("record %q belongs to collection %q: run the command with --collection %s", ref, record.Collection, record.Collection)
+	}
+	return record, err
+}
+
+// resolveSuperseded resolves the references to the records a new record
+// supersedes, prefixed with their collection for records of another one
+// ("payments:ID"). References that cannot be resolved, and archived records,
+// produce a warning and are skipped.
+func resolveSuperseded(service *records.Service, refs []string) []string {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		rcd, err := service.Resolve(ref)
+		switch {
+		case err != nil:
+			printWarning("superseded record: %v, skipping", err)
+		case rcd.Archived:
+			printWarning("superseded record %q is archived, skipping", rcd.ID)
+		default:
+			ids = append(ids, service.Ref(rcd))
 		}
 	}
+	return ids
+}
+
+// markSuperseded completes the supersession a record holds on the records it
+// refers to: the records it supersedes are flagged as superseded by it, and its
+// superseders list it under supersedes. Failures produce a warning.
+func markSuperseded(service *records.Service, record records.AdrData) {
+	if _, err := service.SyncSupersession(record); err != nil {
+		printWarning("unable to update superseded records: %v", err)
+	}
 }
 
-// warnUnknownRecords warns for every id that does not match an existing record.
-func warnUnknownRecords(service *records.Service, ids []string) {
-	for _, id := range ids {
-		if _, ok := service.GetRecord(id); !ok {
-			printWarning("superseder %q does not match any existing record", id)
+// resolveIDs resolves record references to their IDs, prefixed with their
+// collection for records of another one ("payments:ID"). A reference matching no
+// record is kept as is with a warning; an ambiguous one is an error.
+func resolveIDs(service *records.Service, refs []string) ([]string, error) {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		record, err := service.Resolve(ref)
+		switch {
+		case errors.Is(err, records.ErrNotFound):
+			printWarning("superseder %q does not match any existing record", ref)
+			ids = append(ids, ref)
+		case err != nil:
+			return nil, err
+		default:
+			ids = append(ids, service.Ref(record))
 		}
 	}
+	return ids, nil
 }
@@ -28,7 +28,7 @@ Custom templates are picked up from the "templates_dir" declared in the nearest
 					&cli.BoolFlag{Name: "json", Usage: "output templates as JSON"},
 				},
 				Action: func(_ context.Context, cmd *cli.Command) error {
-					reg, err := loadTemplates()
+					reg, err := loadTemplates(cmd)
 					if err != nil {
 						printError("unable to load templates: %v", err)
 						return errSilent
@@ -67,7 +67,7 @@ Custom templates are picked up from the "templates_dir" declared in the nearest
 						return errSilent
 					}
 					name := cmd.Args().First()
-					reg, err := loadTemplates()
+					reg, err := loadTemplates(cmd)
 					if err != nil {
 						printError("unable to load templates: %v", err)
 						return errSilent
@@ -116,9 +116,9 @@ type templateDetail struct {
 
 // loadTemplates loads the template registry, tolerating a missing config so the
 // built-ins are still listed outside an initialized project.
-func loadTemplates() (map[string]templates.Template, error) {
+func loadTemplates(cmd *cli.Command) (map[string]templates.Template, error) {
 	dir := ""
-	if cfg, base, err := records.LoadConfig(); err == nil && cfg.TemplatesDir != "" {
+	if cfg, base, err := records.LoadCollectionConfig(cmd.String("collection")); err == nil && cfg.TemplatesDir != "" {
 		dir = filepath.Join(base, cfg.TemplatesDir)
 	}
 	return templates.Load(dir)
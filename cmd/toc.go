@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	cs "github.com/gwleclerc/adr/constants"
@@ -16,22 +18,65 @@ func tocCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "toc",
 		Usage: "Generate a table of contents for the ADRs",
-		Description: `Generate a markdown index of every record (number, title, status, date).
-Writes to stdout by default, or to a file with --output (e.g. docs/adrs/README.md).`,
+		Description: `Generate a markdown index of every record (number, title, status, date),
+with a section per category.
+Writes to stdout by default, or to a file with --output (e.g. docs/adrs/README.md).
+
+With --recursive, index the records of every project found below the current
+directory (e.g. the services of a monorepo) in one document, with a section per
+project. Links are then relative to the output file's directory.
+
+With --graph, the index ends with a Mermaid diagram of the records and how they relate
+(see "adr graph").`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
 				Usage:   "write the index to a file instead of stdout",
 			},
+			&cli.BoolFlag{
+				Name:    "recursive",
+				Aliases: []string{"R"},
+				Usage:   "index the records of every project (" + cs.ConfigurationFile + " file) below the current directory",
+			},
+			&cli.BoolFlag{
+				Name:  "graph",
+				Usage: "embed a Mermaid graph of the records (the default with \"toc_graph: true\" in " + cs.ConfigurationFile + ")",
+			},
 		},
 		Action: func(_ context.Context, cmd *cli.Command) error {
-			service, err := records.NewService()
-			if err != nil {
-				printError("unable to initialize records service: %v", err)
-				return errSilent
+			var toc string
+			if cmd.Bool("recursive") {
+				if cmd.Bool("graph") {
+					printError("--graph cannot be used with --recursive")
+					return errSilent
+				}
+				services, diagnostics, err := discoverProjects(cmd, records.WithMetadataOnly())
+				if err != nil {
+					printError("%v", err)
+					return errSilent
+				}
+				warnDiagnostics(diagnostics)
+				base := "."
+				if out := cmd.String("output"); out != "" {
+					base = filepath.Dir(out)
+				}
+				toc = renderProjectsTOC(services, base)
+			} else {
+				service, err := newService(cmd, records.WithMetadataOnly())
+				if err != nil {
+					printError("unable to initialize records service: %v", err)
+					return errSilent
+				}
+				toc = renderTOC(service.GetRecords())
+				withGraph := service.TOCGraph()
+				if cmd.IsSet("graph") {
+					withGraph = cmd.Bool("graph")
+				}
+				if withGraph {
+					toc += renderTOCGraph(service.GetRecords(), service.Relations(), service.Workflow())
+				}
 			}
-			toc := renderTOC(service.GetRecords())
 			if out := cmd.String("output"); out != "" {
 				if err := os.WriteFile(out, []byte(toc), 0o644); err != nil {
 					printError("unable to write %q: %v", out, err)
@@ -46,27 +91,117 @@ Writes to stdout by default, or to a file with --output (e.g. docs/adrs/README.m
 	}
 }
 
-// renderTOC builds a markdown index of the records. Links are the record filenames,
-// so the index is meant to live alongside the records (e.g. in the ADR directory).
+// refreshTOC rewrites the table of contents configured with the "toc" key, if
+// any, so it follows the records that were renamed or removed.
+func refreshTOC(service *records.Service) {
+	if service.TOCPath() == "" {
+		return
+	}
+	toc := renderTOC(service.GetRecords())
+	if service.TOCGraph() {
+		toc += renderTOCGraph(service.GetRecords(), service.Relations(), service.Workflow())
+	}
+	if err := service.WriteTOC(toc); err != nil {
+		printWarning("unable to update the table of contents %q: %v", service.TOCPath(), err)
+	}
+}
+
+// renderTOC builds a markdown index of the records, leaving out drafts and
+// archived records, with a section per category. Links are the record paths
+// relative to the ADR directory, so the index is meant to live there.
 func renderTOC(adrs []records.AdrData) string {
+	adrs = indexedRecords(adrs)
 	var b strings.Builder
-	b.WriteString("# Architecture Decision Records\n\n")
+	b.WriteString("# Architecture Decision Records\n")
 	if len(adrs) == 0 {
-		b.WriteString("_No records yet._\n")
+		b.WriteString("\n_No records yet._\n")
 		return b.String()
 	}
-	b.WriteString("| # | Title | Status | Date |\n|---|---|---|---|\n")
-	for _, a := range adrs {
-		number := utils.GetRecordNumber(a.Name)
-		if number == "" {
-			number = "-"
+	writeTOCSections(&b, adrs, "##", func(a records.AdrData) string { return a.Name })
+	return b.String()
+}
+
+// renderProjectsTOC builds a markdown index of the records of several projects,
+// with a section per project (and collection) holding a subsection per
+// category. Links are relative to base, the directory the index is meant to
+// live in.
+func renderProjectsTOC(services []*records.Service, base string) string {
+	var b strings.Builder
+	b.WriteString("# Architecture Decision Records\n")
+	empty := true
+	for _, service := range services {
+		adrs := indexedRecords(service.GetRecords())
+		if len(adrs) == 0 {
+			continue
 		}
-		date := "-"
-		if !a.CreationDate.IsZero() {
-			date = a.CreationDate.Format("2006-01-02")
+		empty = false
+		name := service.Project()
+		if service.Collection() != "" {
+			name += " (" + service.Collection() + ")"
 		}
-		title := strings.ReplaceAll(a.Title, "|", "\\|")
-		fmt.Fprintf(&b, "| %s | [%s](%s) | %s | %s |\n", number, title, a.Name, a.Status, date)
+		fmt.Fprintf(&b, "\n## %s\n", name)
+		writeTOCSections(&b, adrs, "###", func(a records.AdrData) string {
+			link, err := filepath.Rel(base, service.RecordPath(a))
+			if err != nil {
+				link = service.RecordPath(a)
+			}
+			return filepath.ToSlash(link)
+		})
+	}
+	if empty {
+		b.WriteString("\n_No records yet._\n")
 	}
 	return b.String()
 }
+
+// indexedRecords returns the records listed in an index: neither drafts nor
+// archived records.
+func indexedRecords(adrs []records.AdrData) []records.AdrData {
+	return slices.DeleteFunc(slices.Clone(adrs), func(a records.AdrData) bool { return a.Draft || a.Archived })
+}
+
+// writeTOCSections writes a table of the records per category, each category
+// under a heading of the given level.
+func writeTOCSections(b *strings.Builder, adrs []records.AdrData, heading string, link func(records.AdrData) string) {
+	for _, group := range groupByCategory(adrs) {
+		if group.name != "" {
+			fmt.Fprintf(b, "\n%s %s\n", heading, group.name)
+		}
+		b.WriteString("\n| # | Title | Status | Date |\n|---|---|---|---|\n")
+		for _, a := range group.adrs {
+			number := utils.GetRecordNumber(a.Name)
+			if number == "" {
+				number = "-"
+			}
+			date := "-"
+			if !a.CreationDate.IsZero() {
+				date = a.CreationDate.Format("2006-01-02")
+			}
+			title := strings.ReplaceAll(a.Title, "|", "\\|")
+			fmt.Fprintf(b, "| %s | [%s](%s) | %s | %s |\n", number, title, link(a), a.Status, date)
+		}
+	}
+}
+
+type categoryGroup struct {
+	name string
+	adrs []records.AdrData
+}
+
+// groupByCategory splits records by category, uncategorized records first and
+// categories in alphabetical order, keeping the order of the records in each.
+func groupByCategory(adrs []records.AdrData) []categoryGroup {
+	var groups []categoryGroup
+	index := map[string]int{}
+	for _, a := range adrs {
+		i, ok := index[a.Category]
+		if !ok {
+			i = len(groups)
+			index[a.Category] = i
+			groups = append(groups, categoryGroup{name: a.Category})
+		}
+		groups[i].adrs = append(groups[i].adrs, a)
+	}
+	slices.SortStableFunc(groups, func(a, b categoryGroup) int { return strings.Compare(a.name, b.name) })
+	return groups
+}
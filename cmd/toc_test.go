@@ -30,3 +30,22 @@ func TestRenderTOC(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderTOCCategories(t *testing.T) {
+	adrs := []records.AdrData{
+		{Name: "frontend/002_b.md", Title: "B", Status: records.ACCEPTED, Category: "frontend"},
+		{Name: "001_a.md", Title: "A", Status: records.ACCEPTED},
+		{Name: "backend/003_c.md", Title: "C", Status: records.ACCEPTED, Category: "backend"},
+		{Name: "drafts/d.md", Title: "D", Status: records.PROPOSED, Draft: true},
+	}
+	out := renderTOC(adrs)
+	a := strings.Index(out, "[A](001_a.md)")
+	backend := strings.Index(out, "## backend")
+	frontend := strings.Index(out, "## frontend")
+	if a < 0 || backend < a || frontend < backend || !strings.Contains(out, "[C](backend/003_c.md)") {
+		t.Errorf("expected uncategorized records, then a section per category:\n%s", out)
+	}
+	if strings.Contains(out, "[D]") {
+		t.Errorf("drafts should be left out:\n%s", out)
+	}
+}
@@ -2,9 +2,9 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
+	cs "github.com/gwleclerc/adr/constants"
 	"github.com/gwleclerc/adr/records"
 	"github.com/urfave/cli/v3"
 )
@@ -16,9 +16,10 @@ type updateRecordOptions struct {
 	tags           []string
 	setSuperseders bool
 	superseders    []string
+	fields         map[string]any
 }
 
-func updateCommand() *cli.Command {
+func updateCommand(workflow records.Workflow) *cli.Command {
 	return &cli.Command{
 		Name:      "update",
 		Usage:     "Update an ADR",
@@ -26,7 +27,10 @@ func updateCommand() *cli.Command {
 		Description: fmt.Sprintf(`Update an existing architecture decision record.
 It will keep the content and only modify the metadata.
 
-%s`, records.StatusHelp()),
+%s`, workflow.StatusHelp()),
+		// Flag values are split by splitCSV, so a list field value (--field a=x,y)
+		// reaches the action whole.
+		DisableSliceFlagSeparator: true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "author",
@@ -36,7 +40,7 @@ It will keep the content and only modify the metadata.
 			&cli.StringFlag{
 				Name:    "status",
 				Aliases: []string{"s"},
-				Usage:   "status of the record, allowed: " + records.AllowedStatuses(),
+				Usage:   "status of the record, allowed: " + workflow.AllowedStatuses(),
 			},
 			&cli.StringSliceFlag{
 				Name:    "tags",
@@ -48,6 +52,18 @@ It will keep the content and only modify the metadata.
 				Aliases: []string{"r"},
 				Usage:   "superseders of the record (use --superseders= to remove all superseders)",
 			},
+			&cli.StringSliceFlag{
+				Name:  "field",
+				Usage: "custom field declared in " + cs.ConfigurationFile + ", as name=value (use name= to remove it)",
+			},
+			&cli.StringFlag{
+				Name:  "reason",
+				Usage: "reason for the status change, recorded in the history",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "overwrite the record even if it changed on disk, and allow any status change",
+			},
 			&cli.BoolFlag{
 				Name:  "json",
 				Usage: "print the updated record as JSON",
@@ -63,19 +79,24 @@ It will keep the content and only modify the metadata.
 			}
 			recordID := cmd.Args().First()
 
+			service, err := newService(cmd)
+			if err != nil {
+				printError("unable to initialize records service: %v", err)
+				return errSilent
+			}
 			var status records.AdrStatus
 			if cmd.IsSet("status") {
-				s, err := records.ParseStatus(cmd.String("status"))
+				s, err := service.ParseStatus(cmd.String("status"))
 				if err != nil {
 					printError("invalid status: %v", err)
 					return errSilent
 				}
 				status = s
 			}
-
-			service, err := records.NewService()
+			applyChangeFlags(service, cmd)
+			fields, err := parseFieldFlags(service.Fields(), cmd.StringSlice("field"))
 			if err != nil {
-				printError("unable to initialize records service: %v", err)
+				printError("invalid field: %v", err)
 				return errSilent
 			}
 			opts := updateRecordOptions{
@@ -85,13 +106,14 @@ It will keep the content and only modify the metadata.
 				tags:           splitCSV(cmd.StringSlice("tags")),
 				setSuperseders: cmd.IsSet("superseders"),
 				superseders:    splitCSV(cmd.StringSlice("superseders")),
+				fields:         fields,
 			}
 			record, err := updateRecord(service, recordID, opts)
 			if err != nil {
-				printError("unable to update ADR %q: %v", recordID, err)
+				printUpdateError(recordID, err)
 				return errSilent
 			}
-			if err := reportRecord(record, cmd.Bool("json")); err != nil {
+			if err := reportRecord(record, service.Workflow(), cmd.Bool("json")); err != nil {
 				printError("unable to encode record: %v", err)
 				return errSilent
 			}
@@ -101,9 +123,9 @@ It will keep the content and only modify the metadata.
 }
 
 func updateRecord(service *records.Service, recordID string, opts updateRecordOptions) (records.AdrData, error) {
-	record, ok := service.GetRecord(recordID)
-	if !ok {
-		return records.AdrData{}, errors.New("record not found")
+	record, err := resolveLocal(service, recordID)
+	if err != nil {
+		return records.AdrData{}, err
 	}
 
 	if opts.author != "" {
@@ -116,10 +138,11 @@ func updateRecord(service *records.Service, recordID string, opts updateRecordOp
 		record.Tags.Set(opts.tags...)
 	}
 	if opts.setSuperseders {
-		if len(opts.superseders) > 0 {
-			warnUnknownRecords(service, opts.superseders)
+		ids, err := resolveIDs(service, opts.superseders)
+		if err != nil {
+			return records.AdrData{}, err
 		}
-		record.Superseders.Set(opts.superseders...)
+		record.Superseders.Set(ids...)
 		// Gaining superseders implies the record is superseded, unless the user
 		// set an explicit status in this same call.
 		if len(opts.superseders) > 0 && opts.status == "" {
@@ -127,8 +150,15 @@ func updateRecord(service *records.Service, recordID string, opts updateRecordOp
 		}
 	}
 
+	if err := applyFields(&record, service.Fields(), opts.fields); err != nil {
+		return records.AdrData{}, err
+	}
+
 	if err := service.UpdateRecord(record); err != nil {
 		return records.AdrData{}, err
 	}
+	if opts.setSuperseders {
+		markSuperseded(service, record)
+	}
 	return record, nil
 }
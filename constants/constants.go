@@ -5,10 +5,51 @@ import (
 )
 
 type Config struct {
-	Directory       string `yaml:"directory"`
-	TemplatesDir    string `yaml:"templates_dir,omitempty"`
-	DefaultTemplate string `yaml:"default_template,omitempty"`
-	DefaultAuthor   string `yaml:"default_author,omitempty"`
+	Directory       string              `yaml:"directory"`
+	TemplatesDir    string              `yaml:"templates_dir,omitempty"`
+	DefaultTemplate string              `yaml:"default_template,omitempty"`
+	DefaultAuthor   string              `yaml:"default_author,omitempty"`
+	TOC             string              `yaml:"toc,omitempty"`
+	TOCGraph        bool                `yaml:"toc_graph,omitempty"`
+	DraftsDir       string              `yaml:"drafts_dir,omitempty"`
+	ArchiveDir      string              `yaml:"archive_dir,omitempty"`
+	Numbering       string              `yaml:"numbering,omitempty"`
+	Fields          []FieldSpec         `yaml:"fields,omitempty"`
+	Statuses        []StatusSpec        `yaml:"statuses,omitempty"`
+	Transitions     map[string][]string `yaml:"transitions,omitempty"`
+	Relations       []RelationSpec      `yaml:"relations,omitempty"`
+	// Collections declares named ADR logs (e.g. one per service of a monorepo).
+	// Unset keys of a collection are inherited from the top-level ones.
+	Collections       map[string]Config `yaml:"collections,omitempty"`
+	DefaultCollection string            `yaml:"default_collection,omitempty"`
+}
+
+// StatusSpec declares a record status (or overrides a built-in one) with its
+// meaning and the color used to render it.
+type StatusSpec struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Color       string `yaml:"color,omitempty"`
+}
+
+// RelationSpec declares a type of link between records (or overrides a built-in
+// one): its name, read from the linking record (e.g. "amends"), and its inverse,
+// read from the linked one (e.g. "amended-by"). A relation without an inverse is
+// symmetric.
+type RelationSpec struct {
+	Name        string `yaml:"name"`
+	Inverse     string `yaml:"inverse,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// FieldSpec declares a custom front-matter field: its type (string, int, date,
+// enum or list), whether it is required, its default and its allowed values.
+type FieldSpec struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Required bool     `yaml:"required,omitempty"`
+	Default  any      `yaml:"default,omitempty"`
+	Values   []string `yaml:"values,omitempty"`
 }
 
 const (
@@ -22,6 +63,9 @@ var (
 	Green        = gchalk.WithGreen().Sprintf
 	Yellow       = gchalk.WithYellow().Sprintf
 	Grey         = gchalk.WithGrey().Sprintf
+	Blue         = gchalk.WithBlue().Sprintf
+	Magenta      = gchalk.WithMagenta().Sprintf
+	Cyan         = gchalk.WithCyan().Sprintf
 
 	TableHeader = []string{"ID", "Title", "Status", "Author", "Creation Date", "Last Update Date", "Superseders", "Tags"}
 )
@@ -0,0 +1,200 @@
+package records
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"time"
+)
+
+// defaultArchiveDir is where archived records are moved, inside the ADR
+// directory, unless the configuration sets "archive_dir".
+const defaultArchiveDir = "archive"
+
+// ArchiveRecord moves a record to the archive directory (in the same category),
+// where it stays indexed
+// (so references to it remain valid) but read-only. Links to the record in the
+// files of the ADR directory are rewritten; their names are returned along with
+// the archived record.
+func (s Service) ArchiveRecord(record AdrData) (AdrData, []string, error) {
+	if record.Archived {
+		return AdrData{}, nil, &ArchivedError{ID: record.ID}
+	}
+	if record.Draft {
+		return AdrData{}, nil, fmt.Errorf("record %q is a draft: delete it instead", record.ID)
+	}
+
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+	defer unlock()
+
+	if err := s.checkUnchanged(&record); err != nil {
+		return AdrData{}, nil, err
+	}
+	dir := path.Join(s.archivePath, record.Category)
+	if err := s.storage.MkdirAll(dir); err != nil {
+		return AdrData{}, nil, err
+	}
+	newName, err := relPath(s.adrsPath, path.Join(dir, path.Base(record.Name)))
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+	oldName := record.Name
+	if exists(s.storage, s.file(newName)) {
+		return AdrData{}, nil, fmt.Errorf("%q already exists", newName)
+	}
+	if err := s.storage.Rename(s.file(oldName), s.file(newName)); err != nil {
+		return AdrData{}, nil, err
+	}
+
+	record.Name = newName
+	record.Archived = true
+	s.records[record.ID] = record
+	changed, err := s.rewriteLinks(map[string]string{oldName: newName})
+	return record, changed, err
+}
+
+// DeleteRecord removes a record file. Records that refer to it, as a superseder,
+// through a link or in their body ("[[ADR-004]]"), make it fail with a
+// *ReferencedError, unless cascade is set: the references are then removed from
+// them (a body reference becomes its text), and those it was the last superseder
+// of get back the status they had before being superseded. Records that list it
+// under supersedes lose the reference too. Archived records are read-only: when
+// one refers to the record, the deletion fails with an error matching
+// ErrArchived. It returns the names of the records it changed.
+func (s *Service) DeleteRecord(record AdrData, cascade bool) ([]string, error) {
+	ref := s.Ref(record)
+	lookup := s.referenceLookup()
+	refersTo := func(reference string) bool {
+		target, err := lookup(reference)
+		return err == nil && s.Ref(target) == ref
+	}
+	s.indexReferences()
+	var referrers, successors []AdrData
+	for _, r := range s.GetRecords() {
+		switch {
+		case r.ID == record.ID:
+		case r.Superseders[record.ID], r.linksTo(ref), slices.ContainsFunc(r.references, refersTo):
+			referrers = append(referrers, r)
+		case r.Supersedes[record.ID]:
+			successors = append(successors, r)
+		}
+	}
+	for _, r := range slices.Concat(referrers, successors) {
+		if r.Archived {
+			return nil, fmt.Errorf("record %q refers to it: %w", r.ID, &ArchivedError{ID: r.ID})
+		}
+	}
+	if len(referrers) > 0 && !cascade {
+		ids := make([]string, len(referrers))
+		for i, r := range referrers {
+			ids[i] = r.ID
+		}
+		return nil, &ReferencedError{ID: record.ID, By: ids}
+	}
+	referrers = append(referrers, successors...)
+
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := s.checkUnchanged(&record); err != nil {
+		return nil, err
+	}
+	for i := range referrers {
+		if err := s.checkUnchanged(&referrers[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var changed []string
+	for _, r := range referrers {
+		superseded := r.Superseders[record.ID]
+		r.Superseders = without(r.Superseders, record.ID)
+		r.Supersedes = without(r.Supersedes, record.ID)
+		for _, name := range slices.Sorted(maps.Keys(r.Links)) {
+			r.RemoveLink(name, ref)
+		}
+		r.Body = replaceReferences(r.Body, func(_ int, reference, text, match string) string {
+			if !refersTo(reference) {
+				return match
+			}
+			if text == "" {
+				text = record.Title
+			}
+			return text
+		})
+		r.references, r.referencesKnown = referenceTargets(r.Body), true
+		r.LastUpdateDate = time.Now()
+		if superseded && len(r.Superseders) == 0 && r.Status == SUPERSEDED {
+			reason := s.reason
+			if reason == "" {
+				reason = fmt.Sprintf("superseder %q deleted", record.ID)
+			}
+			r.Status = r.statusBeforeSupersession()
+			r.History = append(slices.Clip(r.History), HistoryEntry{
+				Status: r.Status,
+				Date:   r.LastUpdateDate,
+				Actor:  s.actor,
+				Reason: reason,
+			})
+		}
+		header, err := MarshalYAML(r)
+		if err != nil {
+			return changed, err
+		}
+		sum, err := s.writeRecord(r.Name, string(header), r.Body)
+		if err != nil {
+			return changed, err
+		}
+		r.checksum = sum
+		s.records[r.ID] = r
+		changed = append(changed, r.Name)
+	}
+
+	if err := s.storage.Remove(s.file(record.Name)); err != nil {
+		return changed, err
+	}
+	delete(s.records, record.ID)
+	s.ids = slices.DeleteFunc(s.ids, func(id string) bool { return id == record.ID })
+	return changed, nil
+}
+
+// statusBeforeSupersession returns the status a superseded record had before
+// its last change to superseded, according to its history: accepted when the
+// history does not tell.
+func (a AdrData) statusBeforeSupersession() AdrStatus {
+	for i := len(a.History) - 1; i > 0; i-- {
+		if a.History[i].Status == SUPERSEDED && a.History[i-1].Status != SUPERSEDED {
+			return a.History[i-1].Status
+		}
+	}
+	return ACCEPTED
+}
+
+// linksTo tells whether a record links to a record reference, under any
+// relation.
+func (a AdrData) linksTo(ref string) bool {
+	for _, targets := range a.Links {
+		if targets[ref] {
+			return true
+		}
+	}
+	return false
+}
+
+// without returns a copy of a set without an element.
+func without(set Set[string], element string) Set[string] {
+	out := make(Set[string], len(set))
+	for e := range set {
+		if e != element {
+			out[e] = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package records
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestServiceArchiveAndDelete(t *testing.T) {
+	newTestProject(t)
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	for _, r := range []AdrData{
+		{ID: "a", Status: SUPERSEDED, Superseders: Set[string]{"b": true}},
+		{ID: "b", Status: ACCEPTED},
+		{ID: "c", Status: SUPERSEDED, Superseders: Set[string]{"d": true}},
+		{ID: "d", Status: ACCEPTED},
+	} {
+		if _, err := svc.CreateRecord("Record "+r.ID, r, ""); err != nil {
+			t.Fatalf("CreateRecord: %v", err)
+		}
+	}
+
+	svc, _ = NewService()
+	a, _ := svc.GetRecord("a")
+	archived, _, err := svc.ArchiveRecord(a)
+	if err != nil {
+		t.Fatalf("ArchiveRecord: %v", err)
+	}
+	if archived.Name != "archive/001_record_a.md" || !archived.Archived {
+		t.Errorf("archived = %q archived=%v", archived.Name, archived.Archived)
+	}
+	if err := svc.UpdateRecord(archived); !errors.Is(err, ErrArchived) {
+		t.Errorf("UpdateRecord(archived) = %v, want ErrArchived", err)
+	}
+
+	// The archived record is still indexed, and its number is not reused.
+	svc, _ = NewService()
+	if r, ok := svc.GetRecord("a"); !ok || !r.Archived {
+		t.Errorf("archived record not indexed: %+v", r)
+	}
+	e, err := svc.CreateRecord("Record e", AdrData{ID: "e", Status: ACCEPTED}, "")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if e.Name != "005_record_e.md" {
+		t.Errorf("new record = %q, want number 005", e.Name)
+	}
+
+	// An archived record referring to a record is never rewritten.
+	svc, _ = NewService()
+	b, _ := svc.GetRecord("b")
+	if _, err := svc.DeleteRecord(b, true); !errors.Is(err, ErrArchived) {
+		t.Errorf("DeleteRecord(referenced by an archived record) = %v, want ErrArchived", err)
+	}
+
+	d, _ := svc.GetRecord("d")
+	var referenced *ReferencedError
+	if _, err := svc.DeleteRecord(d, false); !errors.As(err, &referenced) || len(referenced.By) != 1 || referenced.By[0] != "c" {
+		t.Fatalf("DeleteRecord(referenced) = %v, want a ReferencedError by c", err)
+	}
+	changed, err := svc.DeleteRecord(d, true)
+	if err != nil {
+		t.Fatalf("DeleteRecord(cascade): %v", err)
+	}
+	if len(changed) != 1 || changed[0] != "003_record_c.md" {
+		t.Errorf("changed = %v", changed)
+	}
+	if _, err := os.Stat(filepath.Join("adrs", "004_record_d.md")); !os.IsNotExist(err) {
+		t.Error("the deleted file should be gone")
+	}
+	if _, ok := svc.GetRecord("d"); ok || len(svc.GetRecords()) != 4 {
+		t.Errorf("deleted record still indexed: %d records", len(svc.GetRecords()))
+	}
+
+	svc, _ = NewService()
+	if c, _ := svc.GetRecord("c"); len(c.Superseders) != 0 || c.Status != ACCEPTED {
+		t.Errorf("superseders = %v, status %q, want none and accepted", c.Superseders, c.Status)
+	}
+}
+
+func TestDeleteRecordLinksAndReferences(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"adrs/001_a.md": "---\nid: a\ntitle: A\nstatus: accepted\n---\n# A\n",
+		"adrs/002_b.md": "---\nid: b\ntitle: B\nstatus: accepted\nlinks:\n  amends: [a]\n---\n# B\n",
+		"adrs/003_c.md": "---\nid: c\ntitle: C\nstatus: accepted\n---\nSee [[ADR-1]] and [[a|the first one]].\n",
+	} {
+		if err := storage.WriteFile(name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"}, WithMetadataOnly())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	a, _ := svc.GetRecord("a")
+	var referenced *ReferencedError
+	if _, err := svc.DeleteRecord(a, false); !errors.As(err, &referenced) || !slices.Equal(referenced.By, []string{"b", "c"}) {
+		t.Fatalf("DeleteRecord = %v, want a ReferencedError by b and c", err)
+	}
+	if _, err := svc.DeleteRecord(a, true); err != nil {
+		t.Fatalf("DeleteRecord(cascade): %v", err)
+	}
+
+	svc, err = Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if b, _ := svc.GetRecord("b"); len(b.Links) != 0 {
+		t.Errorf("links = %v, want none", b.Links)
+	}
+	if c, _ := svc.GetRecord("c"); c.Body != "See A and the first one." {
+		t.Errorf("body = %q, want the references replaced by their text", c.Body)
+	}
+}
+
+func TestDeleteRecordRestoresStatus(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := svc.CreateRecord("Old", AdrData{ID: "old", Status: PROPOSED}, ""); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	superseder, err := svc.CreateRecord("New", AdrData{ID: "new", Status: ACCEPTED, Supersedes: Set[string]{"old": true}}, "")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if _, err := svc.SyncSupersession(superseder); err != nil {
+		t.Fatalf("SyncSupersession: %v", err)
+	}
+	superseder, _ = svc.GetRecord("new")
+	if _, err := svc.DeleteRecord(superseder, true); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+
+	svc, err = Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	old, _ := svc.GetRecord("old")
+	if old.Status != PROPOSED || len(old.Superseders) != 0 {
+		t.Errorf("status = %q, superseders %v, want proposed and none", old.Status, old.Superseders)
+	}
+	if n := len(old.History); n != 3 || old.History[n-1].Status != PROPOSED || old.History[n-1].Reason == "" {
+		t.Errorf("history = %+v, want a last entry back to proposed, with a reason", old.History)
+	}
+}
@@ -0,0 +1,269 @@
+package records
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheFile holds the parsed records of a project, next to its configuration
+	// file, so unchanged files are not parsed again on every command.
+	cacheFile = ".adr/cache"
+	// cacheIgnore keeps the cache out of git: it is local to each checkout. The
+	// rest of the .adr directory (e.g. templates) is left to be committed.
+	cacheIgnore        = ".adr/.gitignore"
+	cacheIgnoreContent = "# Written by adr: the index cache is local to each checkout.\n/cache\n/.gitignore\n"
+	// cacheVersion is bumped whenever the cached data changes shape, which
+	// discards the caches written by older versions.
+	cacheVersion = 6
+	// racyWindow is how recent a modification must be for the file not to be
+	// cached: a file changed again within the timestamp granularity of the file
+	// system could keep its size and modification time.
+	racyWindow = 2 * time.Second
+)
+
+// indexCache maps record files, by storage name, to their parsed content. An entry is valid as long as the file keeps its size and
+// modification time.
+type indexCache struct {
+	storage Storage
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	seen    map[string]bool
+	dirty   bool
+}
+
+type cacheFormat struct {
+	Version int
+	Entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	Size    int64
+	ModTime int64
+	Record  cachedRecord
+}
+
+// cachedRecord is the content of a record file. The front-matter is kept raw:
+// only the keys AdrData does not model need it, and they are parsed on demand.
+// Partial records were indexed without their body (see WithMetadataOnly), and
+// know the references it makes to records once it was read (ReferencesKnown).
+type cachedRecord struct {
+	ID              string
+	Title           string
+	Author          string
+	Status          AdrStatus
+	CreationDate    time.Time
+	LastUpdateDate  time.Time
+	Tags            []string
+	Superseders     []string
+	Supersedes      []string
+	History         []HistoryEntry
+	Links           map[string][]string
+	References      []string
+	ReferencesKnown bool
+	Header          string
+	Body            string
+	Checksum        string
+	HeaderChecksum  string
+	Partial         bool
+}
+
+// loadCache reads the index cache of the project held by storage. A missing,
+// unreadable or outdated cache is simply empty.
+func loadCache(storage Storage) *indexCache {
+	c := &indexCache{
+		storage: storage,
+		entries: map[string]cacheEntry{},
+		seen:    map[string]bool{},
+	}
+	b, err := storage.ReadFile(cacheFile)
+	if err != nil {
+		return c
+	}
+	var stored cacheFormat
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&stored); err != nil || stored.Version != cacheVersion {
+		return c
+	}
+	if stored.Entries != nil {
+		c.entries = stored.Entries
+	}
+	return c
+}
+
+// get returns the cached record of a file, if the file did not change since.
+// With metadataOnly, the record is returned without its body, and partial
+// records are only returned then.
+func (c *indexCache) get(key string, info fs.FileInfo, metadataOnly bool) (AdrData, bool) {
+	if c == nil {
+		return AdrData{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen[key] = true
+	entry, ok := c.entries[key]
+	if !ok || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() || (entry.Record.Partial && !metadataOnly) {
+		return AdrData{}, false
+	}
+	r := entry.Record
+	adr := AdrData{
+		ID:              r.ID,
+		Title:           r.Title,
+		Author:          r.Author,
+		Status:          r.Status,
+		CreationDate:    r.CreationDate,
+		LastUpdateDate:  r.LastUpdateDate,
+		History:         r.History,
+		Extra:           lazyExtras(r.Header),
+		Body:            r.Body,
+		checksum:        r.Checksum,
+		references:      r.References,
+		referencesKnown: r.ReferencesKnown,
+	}
+	if metadataOnly {
+		adr.Body, adr.checksum, adr.partial = "", r.HeaderChecksum, true
+	}
+	if len(r.Tags) > 0 {
+		adr.Tags.Append(r.Tags...)
+	}
+	if len(r.Superseders) > 0 {
+		adr.Superseders.Append(r.Superseders...)
+	}
+	if len(r.Supersedes) > 0 {
+		adr.Supersedes.Append(r.Supersedes...)
+	}
+	for name, targets := range r.Links {
+		for _, target := range targets {
+			adr.AddLink(name, target)
+		}
+	}
+	return adr, true
+}
+
+// put caches the record parsed from a file.
+func (c *indexCache) put(key string, info fs.FileInfo, adr AdrData, content []byte) {
+	if c == nil || time.Since(info.ModTime()) < racyWindow {
+		return
+	}
+	header, _ := rawFrontMatter(content)
+	prefix, err := frontMatterPrefix(bytes.NewReader(content))
+	if err != nil {
+		return
+	}
+	var links map[string][]string
+	for name, targets := range adr.Links {
+		if links == nil {
+			links = map[string][]string{}
+		}
+		links[name] = targets.ToSlice()
+	}
+	entry := cacheEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+		Record: cachedRecord{
+			ID:              adr.ID,
+			Title:           adr.Title,
+			Author:          adr.Author,
+			Status:          adr.Status,
+			CreationDate:    adr.CreationDate,
+			LastUpdateDate:  adr.LastUpdateDate,
+			Tags:            adr.Tags.ToSlice(),
+			Superseders:     adr.Superseders.ToSlice(),
+			Supersedes:      adr.Supersedes.ToSlice(),
+			History:         adr.History,
+			Links:           links,
+			References:      adr.references,
+			ReferencesKnown: adr.referencesKnown,
+			Header:          header,
+			Body:            adr.Body,
+			Checksum:        adr.checksum,
+			HeaderChecksum:  checksum(prefix),
+			Partial:         adr.partial,
+		},
+	}
+	if adr.partial {
+		entry.Record.Checksum = ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen[key] = true
+	c.entries[key] = entry
+	c.dirty = true
+}
+
+// setReferences keeps the references of the body of a file cached without
+// them, read from its content b (whose front-matter is header). Content that is
+// not the one cached is ignored.
+func (c *indexCache) setReferences(key string, b, header []byte, references []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.Record.ReferencesKnown || entry.Size != int64(len(b)) || entry.Record.HeaderChecksum != checksum(header) {
+		return
+	}
+	entry.Record.References, entry.Record.ReferencesKnown = references, true
+	c.entries[key] = entry
+	c.dirty = true
+}
+
+// save writes the cache back when it changed: a command that parsed no file
+// again leaves it alone. Entries below the indexed roots that were not seen
+// belong to files that are gone and are dropped; the entries of other
+// collections of the project are kept. The cache's directory gets a .gitignore
+// leaving it out of git, unless it has one. Failing to write the cache (e.g. in
+// a read-only checkout) is not an error: it is only an optimization.
+func (c *indexCache) save(roots ...string) {
+	if c == nil {
+		return
+	}
+	for key := range c.entries {
+		if c.seen[key] {
+			continue
+		}
+		for _, root := range roots {
+			if root == "." || strings.HasPrefix(key, root+"/") {
+				delete(c.entries, key)
+				c.dirty = true
+				break
+			}
+		}
+	}
+	if !c.dirty {
+		return
+	}
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(cacheFormat{Version: cacheVersion, Entries: c.entries}); err != nil {
+		return
+	}
+	if err := c.storage.MkdirAll(path.Dir(cacheFile)); err != nil {
+		return
+	}
+	if !exists(c.storage, cacheIgnore) {
+		_ = c.storage.WriteFile(cacheIgnore, []byte(cacheIgnoreContent))
+	}
+	_ = c.storage.WriteFile(cacheFile, b.Bytes())
+}
+
+// ClearCache removes the index cache of the project of the nearest
+// configuration file, returning its path. A missing cache is not an error.
+func ClearCache() (string, error) {
+	_, dir, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filepath.FromSlash(cacheFile))
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	return path, nil
+}
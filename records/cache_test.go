@@ -0,0 +1,123 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexCache(t *testing.T) {
+	newTestProject(t)
+	path := filepath.Join("adrs", "001_cached.md")
+	past := time.Now().Add(-time.Hour)
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	title := func(opts ...Option) string {
+		t.Helper()
+		svc, err := NewService(opts...)
+		if err != nil {
+			t.Fatalf("NewService: %v", err)
+		}
+		r, ok := svc.GetRecord("c")
+		if !ok {
+			t.Fatal("record not indexed")
+		}
+		return r.Title
+	}
+
+	write("---\nid: c\ntitle: Aaaa\nstatus: accepted\njira: ADR-1 # ticket\n---\nbody\n")
+	if got := title(); got != "Aaaa" {
+		t.Fatalf("title = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(".adr", "cache")); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(".adr", ".gitignore")); err != nil || !strings.Contains(string(b), "/cache\n") {
+		t.Errorf("the cache should be left out of git: %q, %v", b, err)
+	}
+
+	// Indexing unchanged files does not write the cache again.
+	cachePath := filepath.Join(".adr", "cache")
+	if err := os.Chtimes(cachePath, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if got := title(WithMetadataOnly()); got != "Aaaa" {
+		t.Fatalf("title = %q", got)
+	}
+	if info, err := os.Stat(cachePath); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("the cache was written again for unchanged files: %v", err)
+	}
+
+	// Same size and modification time: the cached record is used.
+	write("---\nid: c\ntitle: Bbbb\nstatus: accepted\njira: ADR-1 # ticket\n---\nbody\n")
+	if got := title(); got != "Aaaa" {
+		t.Errorf("unchanged file should come from the cache, got %q", got)
+	}
+	if got := title(WithoutCache()); got != "Bbbb" {
+		t.Errorf("WithoutCache should parse the file, got %q", got)
+	}
+	past = past.Add(time.Second)
+	write("---\nid: c\ntitle: Bbbb\nstatus: accepted\njira: ADR-1 # ticket\n---\nbody\n")
+	if got := title(); got != "Bbbb" {
+		t.Errorf("modified file should be parsed again, got %q", got)
+	}
+
+	// Records restored from the cache keep their extras and can be updated.
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	r, _ := svc.GetRecord("c")
+	if v, ok := r.Extra.Get("jira"); !ok || v != "ADR-1" || r.Body != "body" {
+		t.Errorf("cached record lost data: jira=%v body=%q", v, r.Body)
+	}
+	r.Status = DEPRECATED
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	b, _ := os.ReadFile(path)
+	if !strings.Contains(string(b), "status: deprecated") || !strings.Contains(string(b), "jira: ADR-1 # ticket") {
+		t.Errorf("updated file:\n%s", b)
+	}
+
+	// Records cached without their body are only used in metadata-only mode.
+	// The references of their body are read once, for backlinks, and cached.
+	past = past.Add(time.Second)
+	write("---\nid: c\ntitle: Cccc\nstatus: accepted\n---\nsee [[ADR-2]]\n")
+	if svc, err = NewService(WithMetadataOnly()); err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if r, _ := svc.GetRecord("c"); r.Title != "Cccc" || r.Body != "" || r.referencesKnown {
+		t.Errorf("metadata-only record = %q with body %q, references known: %v", r.Title, r.Body, r.referencesKnown)
+	}
+	svc.Backlinks(AdrData{ID: "other"})
+	if svc, err = NewService(WithMetadataOnly()); err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if r, _ := svc.GetRecord("c"); r.Body != "" || !slices.Equal(r.references, []string{"ADR-2"}) {
+		t.Errorf("cached references = %v with body %q, want [ADR-2]", r.references, r.Body)
+	}
+	if svc, err = NewService(); err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if r, _ := svc.GetRecord("c"); r.Body != "see [[ADR-2]]" {
+		t.Errorf("full record should have its body, got %q", r.Body)
+	}
+
+	if _, err := ClearCache(); err != nil {
+		t.Fatalf("ClearCache: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(".adr", "cache")); !os.IsNotExist(err) {
+		t.Errorf("cache should be removed, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gwleclerc/adr/utils"
+)
+
+// Record numbering schemes accepted in the "numbering" key of the configuration.
+const (
+	// NumberingGlobal numbers records in a single sequence across categories.
+	NumberingGlobal = "global"
+	// NumberingCategory gives each category its own sequence.
+	NumberingCategory = "category"
+)
+
+// cleanCategory normalizes a category given by the user into the slash-separated
+// subdirectory it stands for, rejecting paths that leave the ADR directory.
+func cleanCategory(category string) (string, error) {
+	category = strings.TrimSpace(filepath.ToSlash(category))
+	if category == "" {
+		return "", nil
+	}
+	clean := path.Clean(category)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid category %q: must be a subdirectory of the ADR directory", category)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	for _, part := range strings.Split(clean, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "", fmt.Errorf("invalid category %q: hidden directories are ignored", category)
+		}
+	}
+	return clean, nil
+}
+
+// checkCategory cleans a category and rejects the ones that would put records in
+// the drafts or archive directory.
+func (s Service) checkCategory(category string) (string, error) {
+	category, err := cleanCategory(category)
+	if err != nil || category == "" {
+		return category, err
+	}
+	dir := s.file(category)
+	for _, reserved := range []string{s.draftsPath, s.archivePath} {
+		if dir == reserved || strings.HasPrefix(dir, reserved+"/") {
+			return "", fmt.Errorf("invalid category %q: it is the drafts or archive directory", category)
+		}
+	}
+	return category, nil
+}
+
+// usedNumbers returns the record numbers taken on disk, including by archived
+// records, and the highest of them: in the whole ADR directory with global
+// numbering, or in the category's directory with per-category numbering.
+func (s Service) usedNumbers(category string) (map[int]bool, int, error) {
+	used := map[int]bool{}
+	highest := 0
+	add := func(name string) {
+		if strings.HasPrefix(name, ".") {
+			return
+		}
+		if number := utils.GetRecordNumber(name); number != "" {
+			n, _ := strconv.Atoi(number)
+			used[n] = true
+			highest = max(highest, n)
+		}
+	}
+
+	if s.perCategory {
+		for _, dir := range []string{s.file(category), path.Join(s.archivePath, category)} {
+			entries, err := s.storage.ReadDir(dir)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, 0, err
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					add(entry.Name())
+				}
+			}
+		}
+		return used, highest, nil
+	}
+
+	for _, root := range []string{s.adrsPath, s.archivePath} {
+		err := fs.WalkDir(s.storage, root, func(p string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root && errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipAll
+				}
+				return err
+			}
+			if entry.IsDir() {
+				if p != root && (strings.HasPrefix(entry.Name(), ".") || p == s.draftsPath) {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			add(entry.Name())
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return used, highest, nil
+}
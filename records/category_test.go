@@ -0,0 +1,108 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanCategory(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"backend", "backend", false},
+		{" backend/api/ ", "backend/api", false},
+		{"./backend", "backend", false},
+		{".", "", false},
+		{"../elsewhere", "", true},
+		{"/abs", "", true},
+		{".hidden", "", true},
+	}
+	for _, tt := range tests {
+		got, err := cleanCategory(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("cleanCategory(%q) = %q, %v; want %q (error: %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestServiceCategories(t *testing.T) {
+	for _, tt := range []struct {
+		numbering string
+		want      []string
+	}{
+		{"", []string{"001_a.md", "backend/002_b.md", "frontend/003_c.md", "backend/004_d.md"}},
+		{"numbering: category\n", []string{"001_a.md", "backend/001_b.md", "frontend/001_c.md", "backend/002_d.md"}},
+	} {
+		t.Run(tt.numbering, func(t *testing.T) {
+			newTestProject(t)
+			if err := os.WriteFile(".adrrc.yml", []byte("directory: adrs\n"+tt.numbering), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			svc, err := NewService()
+			if err != nil {
+				t.Fatalf("NewService: %v", err)
+			}
+			for i, category := range []string{"", "backend", "frontend", "backend"} {
+				id := string(rune('a' + i))
+				r, err := svc.CreateRecord(id, AdrData{ID: id, Status: ACCEPTED, Category: category}, "")
+				if err != nil {
+					t.Fatalf("CreateRecord: %v", err)
+				}
+				if r.Name != tt.want[i] {
+					t.Errorf("record %s = %q, want %q", id, r.Name, tt.want[i])
+				}
+			}
+			if _, err := svc.CreateRecord("x", AdrData{ID: "x", Category: "archive"}, ""); err == nil {
+				t.Error("creating a record in the archive directory should fail")
+			}
+
+			svc, _ = NewService()
+			if r, _ := svc.GetRecord("d"); r.Category != "backend" || r.Name != tt.want[3] {
+				t.Errorf("indexed record = %q in %q", r.Name, r.Category)
+			}
+			if moves := svc.PlanRenumber(false); len(moves) != 0 {
+				t.Errorf("records collide: %v", moves)
+			}
+		})
+	}
+}
+
+func TestRewriteLinksMovedFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "archive"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// 002_b.md was just moved to the archive: its own links and the links to it change.
+	files := map[string]string{
+		"001_a.md":         "see [b](002_b.md) and [web](https://example.com/x.md)\n",
+		"archive/002_b.md": "see [a](001_a.md#context) and [c](./003_c.md)\n",
+		"003_c.md":         "nothing to see\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := Service{storage: NewOSStorage(dir), adrsPath: ".", archivePath: "archive", records: map[string]AdrData{}}
+	changed, err := svc.rewriteLinks(map[string]string{"002_b.md": "archive/002_b.md"})
+	if err != nil {
+		t.Fatalf("rewriteLinks: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("changed = %v, want 2 files", changed)
+	}
+	for name, want := range map[string]string{
+		"001_a.md":         "see [b](archive/002_b.md) and [web](https://example.com/x.md)\n",
+		"archive/002_b.md": "see [a](../001_a.md#context) and [c](../003_c.md)\n",
+	} {
+		b, _ := os.ReadFile(filepath.Join(dir, name))
+		if string(b) != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, b, want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package records
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+// DefaultCollection names the ADR log configured at the top level ("directory")
+// when the configuration also declares collections.
+const DefaultCollection = "default"
+
+// Option configures NewService.
+type Option func(*options)
+
+type options struct {
+	ctx          context.Context
+	collection   string
+	noCache      bool
+	metadataOnly bool
+	// others are the services of the other collections already opened.
+	others *collectionServices
+}
+
+// WithCollection selects the collection to work on, among the ones declared
+// under "collections" in the configuration.
+func WithCollection(name string) Option {
+	return func(o *options) {
+		o.collection = name
+	}
+}
+
+// WithoutCache indexes every record file, without reading or updating the index
+// cache.
+func WithoutCache() Option {
+	return func(o *options) {
+		o.noCache = true
+	}
+}
+
+// WithMetadataOnly indexes the front-matter of the records only, which keeps
+// listing large logs fast and light. Bodies are read on demand by Body, and
+// when a record is rewritten.
+func WithMetadataOnly() Option {
+	return func(o *options) {
+		o.metadataOnly = true
+	}
+}
+
+// WithContext stops indexing the records, with the context's error, once ctx
+// is done.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// selectCollection returns the configuration of a collection, with the keys it
+// does not set inherited from the top level, along with its name. An empty name
+// selects "default_collection", else the top-level directory, else the only
+// collection. The name is "" for projects without collections.
+func selectCollection(root cs.Config, name string) (cs.Config, string, error) {
+	for n := range root.Collections {
+		if n == "" || strings.Contains(n, ":") {
+			return cs.Config{}, "", fmt.Errorf("invalid configuration: invalid collection name %q", n)
+		}
+		if n == DefaultCollection && root.Directory != "" {
+			return cs.Config{}, "", fmt.Errorf("invalid configuration: collection %q clashes with the top-level directory", n)
+		}
+	}
+	if len(root.Collections) == 0 {
+		if name != "" {
+			return cs.Config{}, "", fmt.Errorf("unknown collection %q: no collections are configured", name)
+		}
+		return root, "", nil
+	}
+
+	if name == "" {
+		name = root.DefaultCollection
+	}
+	if name == "" {
+		switch {
+		case root.Directory != "":
+			name = DefaultCollection
+		case len(root.Collections) == 1:
+			for n := range root.Collections {
+				name = n
+			}
+		default:
+			return cs.Config{}, "", fmt.Errorf("several collections are configured, choose one with --collection: %s", strings.Join(collectionNames(root), ", "))
+		}
+	}
+	if name == DefaultCollection && root.Directory != "" {
+		return root, name, nil
+	}
+	c, ok := root.Collections[name]
+	if !ok {
+		return cs.Config{}, "", fmt.Errorf("unknown collection %q: must be one of %s", name, strings.Join(collectionNames(root), ", "))
+	}
+	if c.Directory == "" {
+		return cs.Config{}, "", fmt.Errorf("invalid configuration: collection %q has no directory", name)
+	}
+	return mergeConfig(root, c), name, nil
+}
+
+// LoadCollectionConfig finds the nearest configuration file and returns the
+// configuration of a collection ("" for the default one), along with the
+// directory that contains the file.
+func LoadCollectionConfig(name string) (cs.Config, string, error) {
+	root, dir, err := LoadConfig()
+	if err != nil {
+		return cs.Config{}, "", err
+	}
+	cfg, _, err := selectCollection(root, name)
+	return cfg, dir, err
+}
+
+// mergeConfig returns the configuration of a collection, completed with the
+// top-level keys it does not set.
+func mergeConfig(root, c cs.Config) cs.Config {
+	merged := c
+	merged.Collections, merged.DefaultCollection = nil, ""
+	inherit := func(v *string, top string) {
+		if *v == "" {
+			*v = top
+		}
+	}
+	inherit(&merged.TemplatesDir, root.TemplatesDir)
+	inherit(&merged.DefaultTemplate, root.DefaultTemplate)
+	inherit(&merged.DefaultAuthor, root.DefaultAuthor)
+	inherit(&merged.Numbering, root.Numbering)
+	if merged.Fields == nil {
+		merged.Fields = root.Fields
+	}
+	if merged.Statuses == nil {
+		merged.Statuses = root.Statuses
+	}
+	if merged.Transitions == nil {
+		merged.Transitions = root.Transitions
+	}
+	if merged.Relations == nil {
+		merged.Relations = root.Relations
+	}
+	// The paths below are specific to each log, so they are not inherited.
+	return merged
+}
+
+func collectionNames(root cs.Config) []string {
+	names := slices.Sorted(maps.Keys(root.Collections))
+	if len(names) > 0 && root.Directory != "" {
+		names = append([]string{DefaultCollection}, names...)
+	}
+	return names
+}
+
+// Collection returns the name of the collection the service works on ("" when
+// the project has a single ADR log).
+func (s Service) Collection() string {
+	return s.collection
+}
+
+// Collections returns the names of the collections of the project, or nil when
+// it has a single ADR log.
+func (s Service) Collections() []string {
+	return collectionNames(s.config)
+}
+
+// OpenCollection returns a service working on another collection of the same
+// configuration ("" for the default one). The force, actor and reason settings
+// are carried over, and follow the service's. Each collection is indexed once:
+// the services opened are shared by the service, its copies, and the services
+// it opens, which return the service itself for its own collection.
+func (s *Service) OpenCollection(name string) (*Service, error) {
+	_, name, err := selectCollection(s.config, name)
+	if err != nil {
+		return nil, err
+	}
+	self := s.others.register(s)
+	if name == s.collection {
+		return self, nil
+	}
+	return s.others.open(name, func() (*Service, error) {
+		other, err := newService(s.config, s.storage, options{collection: name, noCache: s.noCache, metadataOnly: s.metadataOnly, others: s.others})
+		if err != nil {
+			return nil, err
+		}
+		other.force, other.actor, other.reason = s.force, s.actor, s.reason
+		return other, nil
+	})
+}
+
+// collectionServices memoizes the services of the collections of a project, by
+// name, along with the error opening one failed with.
+type collectionServices struct {
+	mu     sync.Mutex
+	opened map[string]openedCollection
+}
+
+type openedCollection struct {
+	service *Service
+	err     error
+}
+
+// open returns the service of a collection, opened with newService the first
+// time. A nil collectionServices memoizes nothing.
+func (c *collectionServices) open(name string, newService func() (*Service, error)) (*Service, error) {
+	if c == nil {
+		return newService()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	opened, ok := c.opened[name]
+	if !ok {
+		opened.service, opened.err = newService()
+		c.opened[name] = opened
+	}
+	return opened.service, opened.err
+}
+
+// register records the service of a collection unless one is already, and
+// returns the one recorded. A nil collectionServices returns the service.
+func (c *collectionServices) register(s *Service) *Service {
+	if c == nil {
+		return s
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if opened, ok := c.opened[s.collection]; ok && opened.service != nil {
+		return opened.service
+	}
+	c.opened[s.collection] = openedCollection{service: s}
+	return s
+}
+
+// each calls f with the services opened so far.
+func (c *collectionServices) each(f func(*Service)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, opened := range c.opened {
+		if opened.service != nil {
+			f(opened.service)
+		}
+	}
+}
+
+// For returns the service in charge of a record, which may belong to another
+// collection when it was resolved from a cross-collection reference.
+func (s *Service) For(record AdrData) (*Service, error) {
+	if record.Collection == s.collection {
+		return s, nil
+	}
+	return s.OpenCollection(record.Collection)
+}
+
+// Ref returns how the service's records refer to a record: its ID, prefixed
+// with its collection ("payments:ID") when it belongs to another one.
+func (s Service) Ref(record AdrData) string {
+	if record.Collection == s.collection {
+		return record.ID
+	}
+	return record.Collection + ":" + record.ID
+}
+
+// splitCollectionRef splits a cross-collection reference ("payments:007") when
+// its prefix names a collection of the project.
+func (s Service) splitCollectionRef(ref string) (string, string, bool) {
+	collection, rest, ok := strings.Cut(ref, ":")
+	if !ok || !slices.Contains(s.Collections(), collection) {
+		return "", "", false
+	}
+	return collection, rest, true
+}
+
+// Exists reports whether a record ID, or a cross-collection reference to a
+// record ID ("payments:ID"), designates an existing record.
+func (s Service) Exists(ref string) bool {
+	_, ok := s.lookupRef(ref)
+	return ok
+}
+
+// lookupRef returns the record a record ID, or a cross-collection reference
+// ("payments:ID"), designates. Unlike Resolve, it only matches exact IDs.
+func (s Service) lookupRef(ref string) (AdrData, bool) {
+	if r, ok := s.records[ref]; ok {
+		return r, true
+	}
+	collection, id, ok := s.splitCollectionRef(ref)
+	if !ok {
+		return AdrData{}, false
+	}
+	other, err := s.OpenCollection(collection)
+	if err != nil {
+		return AdrData{}, false
+	}
+	return other.GetRecord(id)
+}
@@ -0,0 +1,119 @@
+package records
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestSelectCollection(t *testing.T) {
+	collections := map[string]cs.Config{
+		"payments": {Directory: "svc/payments/adrs", DefaultAuthor: "Payments team"},
+		"orders":   {Directory: "svc/orders/adrs"},
+	}
+	tests := []struct {
+		name     string
+		root     cs.Config
+		ref      string
+		want     string
+		wantDir  string
+		wantFail bool
+	}{
+		{"no collections", cs.Config{Directory: "adrs"}, "", "", "adrs", false},
+		{"no collections, named", cs.Config{Directory: "adrs"}, "payments", "", "", true},
+		{"top-level directory", cs.Config{Directory: "adrs", Collections: collections}, "", DefaultCollection, "adrs", false},
+		{"top-level by name", cs.Config{Directory: "adrs", Collections: collections}, DefaultCollection, DefaultCollection, "adrs", false},
+		{"named", cs.Config{Directory: "adrs", Collections: collections}, "orders", "orders", "svc/orders/adrs", false},
+		{"default_collection", cs.Config{Collections: collections, DefaultCollection: "payments"}, "", "payments", "svc/payments/adrs", false},
+		{"no default", cs.Config{Collections: collections}, "", "", "", true},
+		{"unknown", cs.Config{Collections: collections}, "billing", "", "", true},
+		{"only one", cs.Config{Collections: map[string]cs.Config{"orders": collections["orders"]}}, "", "orders", "svc/orders/adrs", false},
+		{"clash with the top level", cs.Config{Directory: "adrs", Collections: map[string]cs.Config{DefaultCollection: {Directory: "x"}}}, "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, name, err := selectCollection(tt.root, tt.ref)
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("selectCollection() error = %v, want failure: %v", err, tt.wantFail)
+			}
+			if name != tt.want || cfg.Directory != tt.wantDir {
+				t.Errorf("selectCollection() = %q in %q, want %q in %q", name, cfg.Directory, tt.want, tt.wantDir)
+			}
+		})
+	}
+
+	// Keys a collection does not set are inherited from the top level.
+	root := cs.Config{DefaultAuthor: "Architects", DefaultTemplate: "madr", Collections: collections}
+	cfg, _, _ := selectCollection(root, "payments")
+	if cfg.DefaultAuthor != "Payments team" || cfg.DefaultTemplate != "madr" {
+		t.Errorf("merged config = %+v", cfg)
+	}
+}
+
+func TestServiceCollections(t *testing.T) {
+	newTestProject(t)
+	config := "directory: adrs\ncollections:\n  payments:\n    directory: payments\n"
+	if err := os.WriteFile(".adrrc.yml", []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll("payments", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := NewService(WithCollection("payments"))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if _, err := svc.CreateRecord("Use Stripe", AdrData{ID: "stripe", Status: ACCEPTED}, ""); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("payments", "001_use_stripe.md")); err != nil {
+		t.Errorf("record not created in the collection: %v", err)
+	}
+
+	svc, err = NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if svc.Collection() != DefaultCollection {
+		t.Errorf("Collection() = %q, want %q", svc.Collection(), DefaultCollection)
+	}
+	record, err := svc.Resolve("payments:1")
+	if err != nil {
+		t.Fatalf("Resolve(payments:1): %v", err)
+	}
+	if record.ID != "stripe" || record.Collection != "payments" || svc.Ref(record) != "payments:stripe" {
+		t.Errorf("resolved %q in %q, ref %q", record.ID, record.Collection, svc.Ref(record))
+	}
+	if _, err := svc.Resolve("payments:42"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(payments:42) = %v, want ErrNotFound", err)
+	}
+	if !svc.Exists("payments:stripe") || svc.Exists("payments:ghost") || svc.Exists("stripe") {
+		t.Error("Exists should only find the record through its collection")
+	}
+	owner, err := svc.For(record)
+	if err != nil || owner.Collection() != "payments" {
+		t.Errorf("For() = %v, %v", owner, err)
+	}
+
+	// Each collection is indexed once, and follows the settings of the service.
+	if again, _ := svc.OpenCollection("payments"); again != owner {
+		t.Error("OpenCollection should return the service already opened")
+	}
+	if back, _ := owner.OpenCollection(DefaultCollection); back != svc {
+		t.Error("the opened collection should refer back to the service")
+	}
+	if back, _ := owner.OpenCollection(""); back != svc {
+		t.Error("the default collection should not be indexed again")
+	}
+	if self, _ := svc.OpenCollection(svc.Collection()); self != svc {
+		t.Error("OpenCollection should return the service for its own collection")
+	}
+	svc.SetActor("alice")
+	if owner.actor != "alice" {
+		t.Errorf("actor of the opened collection = %q, want alice", owner.actor)
+	}
+}
@@ -0,0 +1,103 @@
+package records
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DiagnosticKind classifies the problems found while indexing record files.
+type DiagnosticKind string
+
+const (
+	// DiagnosticUnreadable is a file that cannot be read.
+	DiagnosticUnreadable DiagnosticKind = "unreadable"
+	// DiagnosticBadYAML is a missing, unterminated or malformed front-matter.
+	DiagnosticBadYAML DiagnosticKind = "bad-yaml"
+	// DiagnosticBadDate is a creation or last update date that is not a date.
+	DiagnosticBadDate DiagnosticKind = "bad-date"
+	// DiagnosticBadField is a front-matter key whose value has the wrong type.
+	DiagnosticBadField DiagnosticKind = "bad-field"
+	// DiagnosticBadConfig is a configuration file that cannot be loaded.
+	DiagnosticBadConfig DiagnosticKind = "bad-config"
+	// DiagnosticRenumberLeftover is a record file left under a temporary name by
+	// a renumbering that could not complete nor be undone.
+	DiagnosticRenumberLeftover DiagnosticKind = "renumber-leftover"
+)
+
+// Diagnostic is a file left out of the index because it could not be parsed.
+type Diagnostic struct {
+	// File is the path of the file, relative to the ADR directory (or, for a
+	// configuration file, to where the projects were discovered).
+	File string `json:"file"`
+	// Line is the line of the file the problem is on (0 when unknown).
+	Line       int            `json:"line,omitempty"`
+	Kind       DiagnosticKind `json:"kind"`
+	Message    string         `json:"message"`
+	Collection string         `json:"collection,omitempty"`
+	Project    string         `json:"project,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.File, d.Message)
+}
+
+// Diagnostics returns the problems found while indexing the records, in file
+// order.
+func (s Service) Diagnostics() []Diagnostic {
+	return s.diagnostics
+}
+
+var (
+	yamlLineRegex  = regexp.MustCompile(`line (\d+):`)
+	fieldNameRegex = regexp.MustCompile(`'(\w+)`)
+)
+
+// yamlErrorLine returns the line of the file a front-matter parse error points
+// to. The front-matter starts on the second line, after the opening "---".
+func yamlErrorLine(err error) int {
+	m := yamlLineRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n + 1
+}
+
+// fieldKey returns the front-matter key of an AdrData field named in a decoding
+// error (e.g. "* 'Title' expected type 'string'"), or "" when there is none.
+func fieldKey(err error) string {
+	m := fieldNameRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return ""
+	}
+	field, ok := reflect.TypeFor[AdrData]().FieldByName(m[1])
+	if !ok {
+		return ""
+	}
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	return key
+}
+
+// keyLine returns the line of the file a top-level front-matter key is on, or 0
+// when it is absent.
+func keyLine(content []byte, key string) int {
+	if key == "" {
+		return 0
+	}
+	lines := strings.Split(string(content), "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			break
+		}
+		if strings.HasPrefix(lines[i], key+":") {
+			return i + 1
+		}
+	}
+	return 0
+}
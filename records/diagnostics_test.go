@@ -0,0 +1,65 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseContentDiagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		kind    DiagnosticKind
+		line    int
+	}{
+		{"valid", "---\nid: a\ntitle: A\n---\nbody\n", "", 0},
+		{"no front-matter", "# A\n", DiagnosticBadYAML, 1},
+		{"unterminated", "---\nid: a\ntitle: A\n", DiagnosticBadYAML, 1},
+		{"malformed", "---\nid: a\n  title: : A\n---\n", DiagnosticBadYAML, 3},
+		{"bad date", "---\nid: a\ntitle: A\ncreation_date: yesterday\n---\n", DiagnosticBadDate, 4},
+		{"bad field", "---\nid: a\ntitle: [A, B]\n---\n", DiagnosticBadField, 3},
+		{"bad history", "---\nid: a\ntitle: A\nhistory: 3\n---\n", DiagnosticBadField, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diag := parseContent("001_a.md", []byte(tt.content))
+			if tt.kind == "" {
+				if diag != nil {
+					t.Fatalf("unexpected diagnostic %+v", diag)
+				}
+				return
+			}
+			if diag == nil {
+				t.Fatal("expected a diagnostic")
+			}
+			if diag.Kind != tt.kind || diag.Line != tt.line || diag.File != "001_a.md" || diag.Message == "" {
+				t.Errorf("diagnostic = %+v, want %s on line %d", diag, tt.kind, tt.line)
+			}
+		})
+	}
+}
+
+func TestServiceDiagnostics(t *testing.T) {
+	newTestProject(t)
+	if err := os.WriteFile(filepath.Join("adrs", "001_ok.md"), []byte("---\nid: ok\ntitle: OK\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("adrs", "002_broken.md"), []byte("---\nid: broken\ncreation_date: soon\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if len(svc.GetRecords()) != 1 {
+		t.Errorf("the broken record should be left out, got %d records", len(svc.GetRecords()))
+	}
+	diags := svc.Diagnostics()
+	if len(diags) != 1 || diags[0].File != "002_broken.md" || diags[0].Kind != DiagnosticBadDate || diags[0].Line != 3 {
+		t.Errorf("Diagnostics() = %+v", diags)
+	}
+	if got := diags[0].String(); got != "002_broken.md:3: "+diags[0].Message {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// defaultDraftsDir is where drafts are kept, inside the ADR directory, unless the
+// configuration sets "drafts_dir".
+const defaultDraftsDir = "drafts"
+
+// reserveDraftFilename claims "<slug>.md" in the category's directory of the
+// drafts directory, or "<slug>_2.md" and so on when a draft already has that
+// name. The returned name is relative to the ADR directory.
+func (s Service) reserveDraftFilename(category, slug string) (string, error) {
+	dir := path.Join(s.draftsPath, category)
+	if err := s.storage.MkdirAll(dir); err != nil {
+		return "", err
+	}
+	for attempt := 1; attempt <= maxReserveAttempts; attempt++ {
+		base := slug + ".md"
+		if attempt > 1 {
+			base = fmt.Sprintf("%s_%d.md", slug, attempt)
+		}
+		name := path.Join(dir, base)
+		err := s.storage.CreateExclusive(name)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return relPath(s.adrsPath, name)
+	}
+	return "", fmt.Errorf("unable to find a free draft name for %q", slug)
+}
+
+// PromoteRecord turns a draft into a record: it takes the next free number, moves
+// into the ADR directory (in the draft's category) and gets the given status (recorded in its history).
+// Links to the draft in the files of the ADR directory are rewritten; their names
+// are returned along with the promoted record.
+func (s Service) PromoteRecord(record AdrData, status AdrStatus) (AdrData, []string, error) {
+	if !record.Draft {
+		return AdrData{}, nil, fmt.Errorf("record %q is not a draft", record.ID)
+	}
+	if err := s.checkTransition(record.Status, status); err != nil {
+		return AdrData{}, nil, err
+	}
+
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+	defer unlock()
+
+	if err := s.checkUnchanged(&record); err != nil {
+		return AdrData{}, nil, err
+	}
+	filename, err := s.reserveFilename(record.Category, filenameSlug(record.Title))
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+
+	oldName, previous := record.Name, record.Status
+	record.Name = filename
+	record.Draft = false
+	record.Status = status
+	record.LastUpdateDate = time.Now()
+	s.trackStatus(&record, previous)
+
+	header, err := MarshalYAML(record)
+	if err != nil {
+		s.storage.Remove(s.file(filename))
+		return AdrData{}, nil, err
+	}
+	sum, err := s.writeRecord(filename, string(header), record.Body)
+	if err != nil {
+		s.storage.Remove(s.file(filename))
+		return AdrData{}, nil, err
+	}
+	record.checksum = sum
+	s.records[record.ID] = record
+	if err := s.storage.Remove(s.file(oldName)); err != nil {
+		return record, nil, err
+	}
+	changed, err := s.rewriteLinks(map[string]string{oldName: filename})
+	return record, changed, err
+}
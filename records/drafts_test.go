@@ -0,0 +1,72 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceDrafts(t *testing.T) {
+	newTestProject(t)
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if _, err := svc.CreateRecord("Use Postgres", AdrData{ID: "a", Status: ACCEPTED, Tags: make(Set[string])}, "see [draft](drafts/add_replicas.md)\n"); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	for range 2 {
+		if _, err := svc.CreateRecord("Add replicas", AdrData{Status: PROPOSED, Tags: make(Set[string]), Draft: true}, ""); err != nil {
+			t.Fatalf("CreateRecord(draft): %v", err)
+		}
+	}
+	// Same title twice: the second draft gets a suffix instead of a number.
+	for _, name := range []string{"add_replicas.md", "add_replicas_2.md"} {
+		if _, err := os.Stat(filepath.Join("adrs", "drafts", name)); err != nil {
+			t.Errorf("draft %s: %v", name, err)
+		}
+	}
+
+	// Give the first draft a known ID (and no history) to look it up.
+	if err := os.WriteFile(filepath.Join("adrs", "drafts", "add_replicas.md"), []byte("---\nid: b\ntitle: Add replicas\nstatus: proposed\n---\n# Add replicas\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, _ = NewService()
+	draft, ok := svc.GetRecord("b")
+	if !ok || !draft.Draft || draft.Name != "drafts/add_replicas.md" {
+		t.Fatalf("indexed draft = %+v", draft)
+	}
+	if r, err := svc.Resolve("add_replicas.md"); err != nil || r.ID != "b" {
+		t.Errorf("Resolve(add_replicas.md) = %v, %v", r.ID, err)
+	}
+
+	promoted, changed, err := svc.PromoteRecord(draft, ACCEPTED)
+	if err != nil {
+		t.Fatalf("PromoteRecord: %v", err)
+	}
+	if promoted.Name != "002_add_replicas.md" || promoted.Draft || promoted.Status != ACCEPTED {
+		t.Errorf("promoted = %q draft=%v status=%s", promoted.Name, promoted.Draft, promoted.Status)
+	}
+	if len(changed) != 1 || changed[0] != "001_use_postgres.md" {
+		t.Errorf("changed = %v", changed)
+	}
+	if _, err := os.Stat(filepath.Join("adrs", "drafts", "add_replicas.md")); !os.IsNotExist(err) {
+		t.Error("the draft file should be gone")
+	}
+	if _, _, err := svc.PromoteRecord(promoted, ACCEPTED); err == nil {
+		t.Error("promoting a record that is not a draft should fail")
+	}
+
+	svc, _ = NewService()
+	r, _ := svc.GetRecord("b")
+	if len(r.History) != 1 || r.History[0].Status != ACCEPTED {
+		t.Errorf("history = %+v, want the acceptance", r.History)
+	}
+	a, _ := svc.GetRecord("a")
+	if !strings.Contains(a.Body, "[draft](002_add_replicas.md)") {
+		t.Errorf("link not rewritten:\n%s", a.Body)
+	}
+}
@@ -0,0 +1,133 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound reports a reference that matches no record.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict reports that a record changed on disk after it was indexed, so
+// writing the indexed snapshot back would clobber someone else's edit.
+var ErrConflict = errors.New("record changed on disk since it was read")
+
+// ErrTransition reports a status change that the project's workflow forbids.
+var ErrTransition = errors.New("status transition not allowed")
+
+// ErrArchived reports a change to an archived record, which is read-only.
+var ErrArchived = errors.New("record is archived")
+
+// ErrReferenced reports the deletion of a record that other records refer to.
+var ErrReferenced = errors.New("record is referenced by other records")
+
+// ErrReadOnly reports a change to records served by a read-only storage.
+var ErrReadOnly = errors.New("storage is read-only")
+
+// ErrCycle reports records that supersede each other in a loop.
+var ErrCycle = errors.New("supersession cycle")
+
+// ConflictError is the error returned by UpdateRecord on a conflicting write.
+// It matches ErrConflict with errors.Is.
+type ConflictError struct {
+	File string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%q changed on disk since it was read", e.File)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// TransitionError is returned by UpdateRecord when the workflow does not allow a
+// record's status change. It matches ErrTransition with errors.Is.
+type TransitionError struct {
+	From, To AdrStatus
+	Allowed  []AdrStatus
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("status %q cannot be changed to %q: it is final", e.From, e.To)
+	}
+	return fmt.Sprintf("status %q cannot be changed to %q: allowed: %s", e.From, e.To, quoteStatuses(e.Allowed))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrTransition
+}
+
+// NotFoundError is returned by Resolve when a reference matches no record. It
+// matches ErrNotFound with errors.Is.
+type NotFoundError struct {
+	Ref string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("record %q not found", e.Ref)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return ErrNotFound
+}
+
+// AmbiguousError is returned by Resolve when a reference matches several records.
+type AmbiguousError struct {
+	Ref        string
+	Candidates []AdrData
+}
+
+func (e *AmbiguousError) Error() string {
+	candidates := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		candidates[i] = fmt.Sprintf("%s (%s, %q)", c.ID, c.Name, c.Title)
+	}
+	return fmt.Sprintf("%q matches several records: %s", e.Ref, strings.Join(candidates, ", "))
+}
+
+// ArchivedError is returned when writing to an archived record. It matches
+// ErrArchived with errors.Is.
+type ArchivedError struct {
+	ID string
+}
+
+func (e *ArchivedError) Error() string {
+	return fmt.Sprintf("record %q is archived and read-only", e.ID)
+}
+
+func (e *ArchivedError) Unwrap() error {
+	return ErrArchived
+}
+
+// ReferencedError is returned by DeleteRecord when other records refer to the
+// record: as a superseder, through a link or in their body. It matches
+// ErrReferenced with errors.Is.
+type ReferencedError struct {
+	ID string
+	By []string
+}
+
+func (e *ReferencedError) Error() string {
+	return fmt.Sprintf("record %q is referred to by %s", e.ID, strings.Join(e.By, ", "))
+}
+
+func (e *ReferencedError) Unwrap() error {
+	return ErrReferenced
+}
+
+// CycleError is returned by Lineage when records supersede each other in a
+// loop. It matches ErrCycle with errors.Is.
+type CycleError struct {
+	Refs []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("records %s supersede each other in a loop", strings.Join(e.Refs, ", "))
+}
+
+func (e *CycleError) Unwrap() error {
+	return ErrCycle
+}
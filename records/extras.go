@@ -0,0 +1,342 @@
+package records
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Extras carries the front-matter keys that AdrData does not model (e.g. a team's
+// own "deciders" or "jira" keys). It keeps the whole front-matter mapping as read
+// from the file, so rewriting a record preserves the original key order, the
+// formatting of unchanged values and the comments.
+type Extras struct {
+	node *yaml.Node // front-matter mapping as read from disk, nil for new records
+	// lazy holds the raw front-matter of records restored from the index cache,
+	// only parsed when the mapping is needed.
+	lazy *lazyMapping
+}
+
+type lazyMapping struct {
+	once   sync.Once
+	header string
+	node   *yaml.Node
+}
+
+// lazyExtras returns Extras parsed from a raw front-matter on first use.
+func lazyExtras(header string) Extras {
+	return Extras{lazy: &lazyMapping{header: header}}
+}
+
+// mapping returns the front-matter mapping, parsing it first if needed.
+func (e Extras) mapping() *yaml.Node {
+	if e.lazy == nil {
+		return e.node
+	}
+	e.lazy.once.Do(func() {
+		e.lazy.node = parseMapping(e.lazy.header).node
+	})
+	return e.lazy.node
+}
+
+// frontMatterKeys is the set of keys modeled by AdrData fields.
+var frontMatterKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeFor[AdrData]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// parseExtras reads the raw front-matter of a record file into Extras. Files
+// whose front-matter is not a mapping yield empty Extras.
+func parseExtras(content []byte) Extras {
+	header, ok := rawFrontMatter(content)
+	if !ok {
+		return Extras{}
+	}
+	return parseMapping(header)
+}
+
+// parseMapping parses a raw front-matter into Extras.
+func parseMapping(header string) Extras {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(header), &doc); err != nil {
+		return Extras{}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return Extras{}
+	}
+	node := doc.Content[0]
+	// Comments above the first key are attached to the document, keep them.
+	if doc.HeadComment != "" && node.HeadComment == "" {
+		node.HeadComment = doc.HeadComment
+	}
+	return Extras{node: node}
+}
+
+// rawFrontMatter returns the text between the opening and closing "---" lines.
+func rawFrontMatter(content []byte) (string, bool) {
+	lines := strings.Split(string(content), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return "", false
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[1:i], "\n"), true
+		}
+	}
+	return "", false
+}
+
+// Keys returns the extra keys in file order.
+func (e Extras) Keys() []string {
+	var keys []string
+	node := e.mapping()
+	if node == nil {
+		return keys
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i].Value; !frontMatterKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// IsZero reports whether there are no extra keys.
+func (e Extras) IsZero() bool {
+	return len(e.Keys()) == 0
+}
+
+// Get decodes the value of an extra key.
+func (e Extras) Get(key string) (any, bool) {
+	value := e.value(key)
+	if value == nil {
+		return nil, false
+	}
+	var v any
+	if err := value.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// Set adds or replaces an extra key. New keys are appended after the existing
+// ones. The mapping is copied first, so other copies of the record are unaffected.
+func (e *Extras) Set(key string, value any) error {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+	e.clone()
+	if current := e.value(key); current != nil {
+		keepComments(&node, current)
+		*current = node
+		return nil
+	}
+	e.node.Content = append(e.node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &node)
+	return nil
+}
+
+// Delete removes an extra key, if present.
+func (e *Extras) Delete(key string) {
+	if e.value(key) == nil {
+		return
+	}
+	e.clone()
+	for i := 0; i+1 < len(e.node.Content); i += 2 {
+		if e.node.Content[i].Value == key {
+			e.node.Content = append(e.node.Content[:i], e.node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// decode decodes the value of any front-matter key, modeled or not, into v. It
+// is a no-op when the key is absent.
+func (e Extras) decode(key string, v any) error {
+	node := e.mapping()
+	if node == nil {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Decode(v)
+		}
+	}
+	return nil
+}
+
+func (e Extras) value(key string) *yaml.Node {
+	node := e.mapping()
+	if node == nil || frontMatterKeys[key] {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// clone replaces the mapping by a deep copy (or a new one), so mutations do not
+// leak into the index or other copies of the record.
+func (e *Extras) clone() {
+	node := e.mapping()
+	e.lazy = nil
+	if node == nil {
+		e.node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		return
+	}
+	e.node = deepCopy(node)
+}
+
+func deepCopy(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = deepCopy(child)
+	}
+	c.Alias = deepCopy(n.Alias)
+	return &c
+}
+
+// MarshalJSON renders the extra keys as a JSON object, in file order.
+func (e Extras) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range e.Keys() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, _ := e.Get(key)
+		value, err := json.Marshal(jsonCompatible(v))
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// jsonCompatible converts the map[any]any values YAML may produce for nested
+// mappings with non-string keys into something encoding/json accepts.
+func jsonCompatible(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = jsonCompatible(e)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, e := range t {
+			m[yamlKeyString(k)] = jsonCompatible(e)
+		}
+		return m
+	case []any:
+		for i, e := range t {
+			t[i] = jsonCompatible(e)
+		}
+	}
+	return v
+}
+
+func yamlKeyString(k any) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(k)
+	return string(b)
+}
+
+// MarshalYAML renders the record's front-matter. Records read from disk are
+// rendered over their original mapping: keys keep their position and comments,
+// values that did not change keep their original formatting, extra keys are
+// written back untouched, and newly set fields are appended.
+// Records built in memory list their fields first, then their extras.
+func (a AdrData) MarshalYAML() (any, error) {
+	type plain AdrData // drops this method to avoid recursing into it
+	var known yaml.Node
+	if err := known.Encode(plain(a)); err != nil {
+		return nil, err
+	}
+	orig := a.Extra.mapping()
+	if orig == nil {
+		return &known, nil
+	}
+
+	values := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(known.Content); i += 2 {
+		values[known.Content[i].Value] = known.Content[i+1]
+	}
+
+	out := &yaml.Node{Kind: yaml.MappingNode, Tag: orig.Tag, Style: orig.Style, HeadComment: orig.HeadComment, FootComment: orig.FootComment}
+	written := map[string]bool{}
+	for i := 0; i+1 < len(orig.Content); i += 2 {
+		key, value := orig.Content[i], orig.Content[i+1]
+		if !frontMatterKeys[key.Value] {
+			out.Content = append(out.Content, key, value)
+			continue
+		}
+		updated, ok := values[key.Value]
+		if !ok {
+			continue // an omitempty field that is now empty
+		}
+		written[key.Value] = true
+		if !sameValue(value, updated) {
+			keepComments(updated, value)
+			value = updated
+		}
+		out.Content = append(out.Content, key, value)
+	}
+	var added []*yaml.Node
+	for i := 0; i+1 < len(known.Content); i += 2 {
+		if !written[known.Content[i].Value] {
+			added = append(added, known.Content[i], known.Content[i+1])
+		}
+	}
+	if len(written) == 0 {
+		// Only extras so far (a record built in memory): lead with the fields.
+		out.Content = append(added, out.Content...)
+	} else {
+		out.Content = append(out.Content, added...)
+	}
+	return out, nil
+}
+
+// sameValue reports whether two nodes hold the same data, ignoring style.
+func sameValue(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !sameValue(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func keepComments(dst, src *yaml.Node) {
+	dst.HeadComment, dst.LineComment, dst.FootComment = src.HeadComment, src.LineComment, src.FootComment
+}
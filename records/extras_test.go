@@ -0,0 +1,114 @@
+package records
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const recordWithExtras = `---
+# Reviewed quarterly.
+id: abc
+title: Use Postgres
+deciders: # who signed off
+  - alice
+  - bob
+author: me
+status: proposed
+creation_date: 2026-01-02T03:04:05Z
+last_update_date: 2026-01-02T03:04:05Z
+jira: ARCH-12
+tags:
+  - db
+review_by: 2027-01-01
+---
+
+# Use Postgres
+
+Body.
+`
+
+func TestExtrasRoundTrip(t *testing.T) {
+	newTestProject(t)
+	path := filepath.Join("adrs", "001_use_postgres.md")
+	if err := os.WriteFile(path, []byte(recordWithExtras), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	r, ok := svc.GetRecord("abc")
+	if !ok {
+		t.Fatal("record abc not found")
+	}
+	if got, want := strings.Join(r.Extra.Keys(), ","), "deciders,jira,review_by"; got != want {
+		t.Errorf("Extra.Keys() = %q, want %q", got, want)
+	}
+
+	r.Author = "you"
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the author and the update date change; everything else is kept as is.
+	lines := strings.Split(string(b), "\n")
+	want := strings.Split(strings.Replace(recordWithExtras, "author: me", "author: you", 1), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("rewritten record has %d lines, want %d:\n%s", len(lines), len(want), b)
+	}
+	for i := range want {
+		if strings.HasPrefix(want[i], "last_update_date:") {
+			continue
+		}
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+}
+
+func TestExtrasSetDelete(t *testing.T) {
+	r := AdrData{ID: "a", Title: "A", Status: ACCEPTED}
+	if err := r.Extra.Set("jira", "ARCH-1"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := r.Extra.Set("deciders", []string{"alice"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := r.Extra.Set("jira", "ARCH-2"); err != nil {
+		t.Fatalf("Set (replace): %v", err)
+	}
+	if v, ok := r.Extra.Get("jira"); !ok || v != "ARCH-2" {
+		t.Errorf("Get(jira) = %v, %v, want ARCH-2", v, ok)
+	}
+
+	// Copies made before a mutation do not see it.
+	before := r
+	r.Extra.Delete("deciders")
+	if _, ok := before.Extra.Get("deciders"); !ok {
+		t.Error("Delete should not affect an earlier copy of the record")
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"extra":{"jira":"ARCH-2"}`) {
+		t.Errorf("JSON should carry the extra keys, got %s", b)
+	}
+
+	header, err := MarshalYAML(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(header), "jira: ARCH-2\n") {
+		t.Errorf("extra keys should be written after the known ones, got:\n%s", header)
+	}
+}
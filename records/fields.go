@@ -0,0 +1,190 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+// Custom field types accepted in the "fields" section of the configuration.
+const (
+	FieldString = "string"
+	FieldInt    = "int"
+	FieldDate   = "date"
+	FieldEnum   = "enum"
+	FieldList   = "list"
+)
+
+// FieldTypes lists every allowed custom field type.
+var FieldTypes = []string{FieldString, FieldInt, FieldDate, FieldEnum, FieldList}
+
+// fieldDateLayout is the format custom date fields are written in.
+const fieldDateLayout = "2006-01-02"
+
+// validateFieldSpecs checks the custom fields declared in the configuration and
+// returns a copy of them with normalized defaults, leaving the configuration
+// untouched.
+func validateFieldSpecs(specs []cs.FieldSpec) ([]cs.FieldSpec, error) {
+	specs = slices.Clone(specs)
+	seen := map[string]bool{}
+	for i, spec := range specs {
+		switch {
+		case spec.Name == "":
+			return nil, fmt.Errorf("field #%d has no name", i+1)
+		case frontMatterKeys[spec.Name]:
+			return nil, fmt.Errorf("field %q clashes with a built-in record field", spec.Name)
+		case seen[spec.Name]:
+			return nil, fmt.Errorf("field %q is declared twice", spec.Name)
+		case !slices.Contains(FieldTypes, spec.Type):
+			return nil, fmt.Errorf("field %q has invalid type %q: must be one of %s", spec.Name, spec.Type, strings.Join(FieldTypes, ", "))
+		case spec.Type == FieldEnum && len(spec.Values) == 0:
+			return nil, fmt.Errorf("enum field %q declares no values", spec.Name)
+		}
+		seen[spec.Name] = true
+		if spec.Default != nil {
+			v, err := NormalizeFieldValue(spec, spec.Default)
+			if err != nil {
+				return nil, fmt.Errorf("invalid default for field %q: %w", spec.Name, err)
+			}
+			specs[i].Default = v
+		}
+	}
+	return specs, nil
+}
+
+// ParseFieldValue converts a raw command-line value into the typed value stored
+// in the front-matter. List values are comma-separated.
+func ParseFieldValue(spec cs.FieldSpec, raw string) (any, error) {
+	if spec.Type == FieldList {
+		items := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return NormalizeFieldValue(spec, items)
+	}
+	return NormalizeFieldValue(spec, strings.TrimSpace(raw))
+}
+
+// NormalizeFieldValue checks a value read from the front-matter (or the
+// configuration) against its field declaration, and returns it in the canonical
+// form it is written in: a string, an int, a "YYYY-MM-DD" string or a []string.
+func NormalizeFieldValue(spec cs.FieldSpec, v any) (any, error) {
+	switch spec.Type {
+	case FieldInt:
+		switch t := v.(type) {
+		case int:
+			return t, nil
+		case string:
+			n, err := strconv.Atoi(t)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an integer", t)
+			}
+			return n, nil
+		}
+		return nil, fmt.Errorf("%v is not an integer", v)
+	case FieldDate:
+		switch t := v.(type) {
+		case time.Time:
+			return t.Format(fieldDateLayout), nil
+		case string:
+			d, err := time.Parse(fieldDateLayout, t)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a date (expected YYYY-MM-DD)", t)
+			}
+			return d.Format(fieldDateLayout), nil
+		}
+		return nil, fmt.Errorf("%v is not a date (expected YYYY-MM-DD)", v)
+	case FieldList:
+		items, ok := v.([]any)
+		if !ok {
+			if s, isStrings := v.([]string); isStrings {
+				for _, item := range s {
+					items = append(items, item)
+				}
+			} else {
+				return nil, fmt.Errorf("%v is not a list", v)
+			}
+		}
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			s, err := scalarString(item)
+			if err != nil {
+				return nil, err
+			}
+			if len(spec.Values) > 0 && !slices.Contains(spec.Values, s) {
+				return nil, fmt.Errorf("%q is not allowed: must be one of %s", s, strings.Join(spec.Values, ", "))
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default: // string and enum
+		s, err := scalarString(v)
+		if err != nil {
+			return nil, err
+		}
+		if (spec.Type == FieldEnum || len(spec.Values) > 0) && !slices.Contains(spec.Values, s) {
+			return nil, fmt.Errorf("%q is not allowed: must be one of %s", s, strings.Join(spec.Values, ", "))
+		}
+		return s, nil
+	}
+}
+
+// CompleteFields fills values with the defaults of the fields that were not
+// given, and fails on a required field left without a value.
+func CompleteFields(specs []cs.FieldSpec, values map[string]any) error {
+	var missing []string
+	for _, spec := range specs {
+		if values[spec.Name] != nil {
+			continue
+		}
+		switch {
+		case spec.Default != nil:
+			values[spec.Name] = spec.Default
+		case spec.Required:
+			missing = append(missing, spec.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required field(s): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// FormatFieldValue renders a custom field value for display.
+func FormatFieldValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return t.Format(fieldDateLayout)
+	case []any:
+		items := make([]string, 0, len(t))
+		for _, item := range t {
+			items = append(items, FormatFieldValue(item))
+		}
+		return strings.Join(items, ", ")
+	case []string:
+		return strings.Join(t, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// scalarString converts a scalar YAML value to its string form.
+func scalarString(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case int, int64, float64, bool:
+		return fmt.Sprint(t), nil
+	case time.Time:
+		return t.Format(fieldDateLayout), nil
+	}
+	return "", errors.New("value must be a single value, not a list or a mapping")
+}
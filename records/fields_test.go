@@ -0,0 +1,118 @@
+package records
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestParseFieldValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    cs.FieldSpec
+		raw     string
+		want    any
+		wantErr bool
+	}{
+		{"string", cs.FieldSpec{Type: FieldString}, " ARCH-12 ", "ARCH-12", false},
+		{"int", cs.FieldSpec{Type: FieldInt}, "42", 42, false},
+		{"bad int", cs.FieldSpec{Type: FieldInt}, "many", nil, true},
+		{"date", cs.FieldSpec{Type: FieldDate}, "2027-01-01", "2027-01-01", false},
+		{"bad date", cs.FieldSpec{Type: FieldDate}, "01/01/2027", nil, true},
+		{"enum", cs.FieldSpec{Type: FieldEnum, Values: []string{"low", "high"}}, "high", "high", false},
+		{"bad enum", cs.FieldSpec{Type: FieldEnum, Values: []string{"low", "high"}}, "medium", nil, true},
+		{"list", cs.FieldSpec{Type: FieldList}, "alice, bob", []string{"alice", "bob"}, false},
+		{"restricted list", cs.FieldSpec{Type: FieldList, Values: []string{"alice"}}, "alice,bob", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFieldValue(tt.spec, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFieldValue(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFieldValue(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFieldValueFromYAML(t *testing.T) {
+	// Values as decoded from the front-matter.
+	date := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, err := NormalizeFieldValue(cs.FieldSpec{Type: FieldDate}, date); err != nil || got != "2027-01-01" {
+		t.Errorf("date from YAML = %v, %v", got, err)
+	}
+	if got, err := NormalizeFieldValue(cs.FieldSpec{Type: FieldList}, []any{"a", 1}); err != nil || !reflect.DeepEqual(got, []string{"a", "1"}) {
+		t.Errorf("list from YAML = %v, %v", got, err)
+	}
+	if _, err := NormalizeFieldValue(cs.FieldSpec{Type: FieldString}, []any{"a"}); err == nil {
+		t.Error("a list should not be accepted for a string field")
+	}
+}
+
+func TestValidateFieldSpecs(t *testing.T) {
+	tests := []struct {
+		name  string
+		specs []cs.FieldSpec
+		ok    bool
+	}{
+		{"valid", []cs.FieldSpec{{Name: "jira", Type: FieldString}, {Name: "risk", Type: FieldEnum, Values: []string{"low"}, Default: "low"}}, true},
+		{"no name", []cs.FieldSpec{{Type: FieldString}}, false},
+		{"built-in clash", []cs.FieldSpec{{Name: "status", Type: FieldString}}, false},
+		{"duplicate", []cs.FieldSpec{{Name: "a", Type: FieldString}, {Name: "a", Type: FieldInt}}, false},
+		{"bad type", []cs.FieldSpec{{Name: "a", Type: "float"}}, false},
+		{"enum without values", []cs.FieldSpec{{Name: "a", Type: FieldEnum}}, false},
+		{"invalid default", []cs.FieldSpec{{Name: "a", Type: FieldInt, Default: "x"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validateFieldSpecs(tt.specs); (err == nil) != tt.ok {
+				t.Errorf("validateFieldSpecs() error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateFieldSpecsCopies(t *testing.T) {
+	cfg := cs.Config{Directory: "adrs", Fields: []cs.FieldSpec{{Name: "teams", Type: FieldList, Default: []any{"core"}}}}
+	specs, err := validateFieldSpecs(cfg.Fields)
+	if err != nil {
+		t.Fatalf("validateFieldSpecs: %v", err)
+	}
+	if _, ok := specs[0].Default.([]string); !ok {
+		t.Errorf("normalized default = %#v, want a []string", specs[0].Default)
+	}
+
+	// The configuration, shared by the collections and reopened services, is
+	// left as it was read.
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(storage, cfg); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, ok := cfg.Fields[0].Default.([]any); !ok {
+		t.Errorf("configured default = %#v, want it left a []any", cfg.Fields[0].Default)
+	}
+}
+
+func TestCompleteFields(t *testing.T) {
+	specs := []cs.FieldSpec{
+		{Name: "risk", Type: FieldEnum, Values: []string{"low", "high"}, Default: "low"},
+		{Name: "jira", Type: FieldString, Required: true},
+	}
+	values := map[string]any{"jira": "ARCH-1"}
+	if err := CompleteFields(specs, values); err != nil {
+		t.Fatalf("CompleteFields: %v", err)
+	}
+	if values["risk"] != "low" {
+		t.Errorf("default not applied: %v", values)
+	}
+	if err := CompleteFields(specs, map[string]any{}); err == nil {
+		t.Error("a missing required field should be reported")
+	}
+}
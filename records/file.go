@@ -1,14 +1,22 @@
 package records
 
 import (
+	"bufio"
 	"bytes"
 	"cmp"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gernest/front"
@@ -32,39 +40,215 @@ func LoadConfig() (cs.Config, string, error) {
 	if err != nil {
 		return cs.Config{}, "", err
 	}
+	return readConfig(path)
+}
+
+// readConfig parses a configuration file and returns it along with the
+// directory that contains it.
+func readConfig(path string) (cs.Config, string, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return cs.Config{}, "", err
 	}
+	config, err := parseConfig(b)
+	return config, filepath.Dir(path), err
+}
+
+// ReadConfig parses the configuration file at the root of a storage.
+func ReadConfig(storage Storage) (cs.Config, error) {
+	b, err := storage.ReadFile(cs.ConfigurationFile)
+	if err != nil {
+		return cs.Config{}, err
+	}
+	return parseConfig(b)
+}
+
+func parseConfig(b []byte) (cs.Config, error) {
 	var config cs.Config
 	if err := yaml.Unmarshal(b, &config); err != nil {
-		return cs.Config{}, "", err
+		return cs.Config{}, err
 	}
-	return config, filepath.Dir(path), nil
+	return config, nil
 }
 
-func indexADRs(path string) ([]AdrData, error) {
-	entries, err := os.ReadDir(path)
+// indexTree reads the records below root, recursively: the ADR directory itself,
+// or the drafts or archive directory. Each record's Name is its path relative to
+// the ADR directory, and its Category the subdirectory of root it is in. In the
+// ADR directory (numbered set) only files that look like records ("NNN_*.md")
+// are indexed, which ignores a generated index (README.md) or any other stray
+// file. Hidden files and directories (the lock, temporary files) and the skipped
+// directories are ignored. A missing root simply holds no records. Files that
+// cannot be parsed are left out and reported as diagnostics, in file order, as
+// are the record files an interrupted renumbering left under a temporary name. Files are
+// parsed concurrently, and not at all when the cache (nil for none) holds them.
+// With metadataOnly, only their front-matter is read. Paths are storage names.
+// Indexing stops with the context's error once ctx is done.
+func indexTree(ctx context.Context, storage Storage, adrsPath, root string, numbered bool, cache *indexCache, metadataOnly bool, skip ...string) ([]AdrData, []Diagnostic, error) {
+	type file struct {
+		name, category string
+		info           fs.FileInfo
+	}
+	var files []file
+	var leftovers []Diagnostic
+	err := fs.WalkDir(storage, root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if p != root && (strings.HasPrefix(entry.Name(), ".") || slices.Contains(skip, p)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if original, ok := strings.CutPrefix(entry.Name(), renumberPrefix); ok && path.Ext(original) == ".md" {
+			name, err := relPath(adrsPath, p)
+			if err != nil {
+				return err
+			}
+			leftovers = append(leftovers, Diagnostic{File: name, Kind: DiagnosticRenumberLeftover, Message: fmt.Sprintf("left by an interrupted renumbering: rename it back to %q", original)})
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") || path.Ext(entry.Name()) != ".md" {
+			return nil
+		}
+		if numbered && utils.GetRecordNumber(entry.Name()) == "" {
+			return nil
+		}
+		name, err := relPath(adrsPath, p)
+		if err != nil {
+			return err
+		}
+		category, err := relPath(root, path.Dir(p))
+		if err != nil {
+			return err
+		}
+		if category == "." {
+			category = ""
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, file{name, category, info})
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	res := make([]AdrData, 0, len(entries))
-	for _, entry := range entries {
-		if entry.IsDir() {
+	parsed := make([]AdrData, len(files))
+	diags := make([]*Diagnostic, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				parsed[i], diags[i] = loadADR(storage, adrsPath, files[i].name, files[i].info, cache, metadataOnly)
+				parsed[i].Category = files[i].category
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var res []AdrData
+	var diagnostics []Diagnostic
+	for i, adr := range parsed {
+		if diags[i] != nil {
+			diagnostics = append(diagnostics, *diags[i])
 			continue
 		}
-		// Only index files that look like records ("NNN_*.md"); this ignores a
-		// generated index (README.md) or any other stray file in the directory.
-		if utils.GetRecordNumber(entry.Name()) == "" {
-			continue
+		res = append(res, adr)
+	}
+	diagnostics = append(diagnostics, leftovers...)
+	// Drafts keep the order of their filenames: they are not numbered, even
+	// when their name starts with digits ("2024_roadmap.md").
+	if numbered {
+		sortByNumber(res)
+	}
+	return res, diagnostics, nil
+}
+
+// loadADR returns a record from the cache when its file did not change, or
+// parses it (and caches it). With metadataOnly, only the front-matter is read:
+// the record's body, and the references it makes, are left to be loaded on
+// demand.
+func loadADR(storage Storage, dir, name string, info fs.FileInfo, cache *indexCache, metadataOnly bool) (AdrData, *Diagnostic) {
+	filePath := path.Join(dir, name)
+	if adr, ok := cache.get(filePath, info, metadataOnly); ok {
+		adr.Name = name
+		return adr, nil
+	}
+	read := storage.ReadFile
+	if metadataOnly {
+		read = func(name string) ([]byte, error) { return readFrontMatter(storage, name) }
+	}
+	b, err := read(filePath)
+	if err != nil {
+		return AdrData{}, &Diagnostic{File: name, Kind: DiagnosticUnreadable, Message: err.Error()}
+	}
+	adr, diag := parseContent(name, b)
+	if diag == nil {
+		if metadataOnly {
+			adr.partial, adr.references, adr.referencesKnown = true, nil, false
 		}
-		if adr, ok := parseADR(path, entry.Name()); ok {
-			res = append(res, adr)
+		cache.put(filePath, info, adr, b)
+	}
+	return adr, diag
+}
+
+// readFrontMatter reads a record file up to the end of its front-matter.
+func readFrontMatter(storage Storage, name string) ([]byte, error) {
+	f, err := storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return frontMatterPrefix(f)
+}
+
+// frontMatterPrefix returns the content up to the line closing the front-matter,
+// included. Content without front-matter is returned up to its first line.
+func frontMatterPrefix(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	var b bytes.Buffer
+	for n := 0; ; n++ {
+		line, err := br.ReadBytes('\n')
+		b.Write(line)
+		if err == io.EOF {
+			return b.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fence := string(bytes.TrimSpace(line)) == "---"
+		if (n == 0 && !fence) || (n > 0 && fence) {
+			return b.Bytes(), nil
 		}
 	}
-	// Sort by the numeric record prefix (so 1000 sorts after 999, unlike a plain
-	// string sort), falling back to the filename for records without a number.
+}
+
+// sortByNumber sorts records by their numeric prefix (so 1000 sorts after 999,
+// unlike a plain string sort), falling back to the filename for records without
+// a number.
+func sortByNumber(res []AdrData) {
 	slices.SortFunc(res, func(a, b AdrData) int {
 		na, _ := strconv.Atoi(utils.GetRecordNumber(a.Name))
 		nb, _ := strconv.Atoi(utils.GetRecordNumber(b.Name))
@@ -73,45 +257,59 @@ func indexADRs(path string) ([]AdrData, error) {
 		}
 		return cmp.Compare(a.Name, b.Name)
 	})
-	return res, nil
 }
 
-// parseADR reads and parses a single ADR file. It returns ok=false (after logging
-// a warning to stderr) when the file cannot be read or parsed, so one bad file
-// does not abort indexing.
-func parseADR(dir, name string) (AdrData, bool) {
-	adrData := AdrData{Name: name}
-	filePath := filepath.Join(dir, name)
-
-	b, err := os.ReadFile(filePath)
+// parseADR reads and parses a single ADR file. It returns a diagnostic when the
+// file cannot be read or parsed, so one bad file does not abort indexing.
+func parseADR(storage Storage, dir, name string) (AdrData, *Diagnostic) {
+	b, err := storage.ReadFile(path.Join(dir, name))
 	if err != nil {
-		fmt.Fprintln(os.Stderr, cs.Yellow("Unable to read file %q: %s", filePath, err.Error()))
-		return AdrData{}, false
+		return AdrData{}, &Diagnostic{File: name, Kind: DiagnosticUnreadable, Message: err.Error()}
 	}
+	return parseContent(name, b)
+}
 
+// parseContent parses the content of the ADR file name.
+func parseContent(name string, b []byte) (AdrData, *Diagnostic) {
+	diagnostic := func(kind DiagnosticKind, line int, format string, a ...any) (AdrData, *Diagnostic) {
+		return AdrData{}, &Diagnostic{File: name, Line: line, Kind: kind, Message: fmt.Sprintf(format, a...)}
+	}
+	// The parser fails on, or does not survive, a front-matter that is missing
+	// or never closed.
+	if _, ok := rawFrontMatter(b); !ok {
+		return diagnostic(DiagnosticBadYAML, 1, "missing or unterminated yaml header")
+	}
+	adrData := AdrData{Name: name}
 	data, body, err := matter.Parse(bytes.NewReader(b))
 	if err != nil {
-		fmt.Fprintln(os.Stderr, cs.Yellow("Unable to read yaml header from file %q: %s", filePath, err.Error()))
-		return AdrData{}, false
+		return diagnostic(DiagnosticBadYAML, yamlErrorLine(err), "invalid yaml header: %v", err)
 	}
 	adrData.Body = body
+	adrData.references, adrData.referencesKnown = referenceTargets(body), true
+	adrData.checksum = checksum(b)
+	adrData.Extra = parseExtras(b)
 
 	if err := processDate(data, "creation_date"); err != nil {
-		fmt.Fprintln(os.Stderr, cs.Yellow("Invalid creation date in yaml header from file %q: %v", filePath, err))
-		return AdrData{}, false
+		return diagnostic(DiagnosticBadDate, keyLine(b, "creation_date"), "invalid creation date: %v", err)
 	}
 	if err := processDate(data, "last_update_date"); err != nil {
-		fmt.Fprintln(os.Stderr, cs.Yellow("Invalid last update date in yaml header from file %q: %v", filePath, err))
-		return AdrData{}, false
+		return diagnostic(DiagnosticBadDate, keyLine(b, "last_update_date"), "invalid last update date: %v", err)
 	}
 	processSet(data, "tags")
 	processSet(data, "superseders")
+	processSet(data, "supersedes")
 
 	if err := mapstructure.Decode(data, &adrData); err != nil {
-		fmt.Fprintln(os.Stderr, cs.Yellow("Invalid yaml header in file %q: %v", filePath, err))
-		return AdrData{}, false
+		return diagnostic(DiagnosticBadField, keyLine(b, fieldKey(err)), "invalid yaml header: %v", err)
+	}
+	// Nested structures are decoded from the YAML tree, which keeps their dates typed.
+	if err := adrData.Extra.decode("history", &adrData.History); err != nil {
+		return diagnostic(DiagnosticBadField, keyLine(b, "history"), "invalid history: %v", err)
+	}
+	if err := adrData.Extra.decode("links", &adrData.Links); err != nil {
+		return diagnostic(DiagnosticBadField, keyLine(b, "links"), "invalid links: %v", err)
 	}
-	return adrData, true
+	return adrData, nil
 }
 
 // processDate normalizes a front-matter date into a time.Time. A missing date
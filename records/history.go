@@ -0,0 +1,78 @@
+package records
+
+import "time"
+
+// StatusAt returns the status the record had at a given instant, according to
+// its history. A record without history is assumed to have always had its current
+// status; one whose history starts after the instant had an unknown status.
+func (a AdrData) StatusAt(at time.Time) AdrStatus {
+	if len(a.History) == 0 {
+		return a.Status
+	}
+	status := UNKNOWN
+	for _, h := range a.History {
+		if h.Date.After(at) {
+			break
+		}
+		status = h.Status
+	}
+	return status
+}
+
+// existedAt reports whether the record had been created at a given instant.
+// Records without a creation date are assumed to have always existed.
+func (a AdrData) existedAt(at time.Time) bool {
+	return a.CreationDate.IsZero() || !a.CreationDate.After(at)
+}
+
+// GetRecordsAsOf reconstructs the records as they stood at a given instant:
+// records created later are left out, each status is the one in force at that
+// time, the history stops there, and only superseders that already existed are
+// kept on records that were superseded by then (and only the records superseded
+// by then are kept under supersedes).
+func (s Service) GetRecordsAsOf(at time.Time) []AdrData {
+	existing := map[string]bool{}
+	for _, r := range s.records {
+		if r.existedAt(at) {
+			existing[r.ID] = true
+		}
+	}
+
+	out := make([]AdrData, 0, len(s.ids))
+	for _, r := range s.GetRecords() {
+		if !existing[r.ID] {
+			continue
+		}
+		r.Status = r.StatusAt(at)
+
+		var history []HistoryEntry
+		for _, h := range r.History {
+			if h.Date.After(at) {
+				break
+			}
+			history = append(history, h)
+		}
+		r.History = history
+
+		superseders := make(Set[string])
+		if r.Status == SUPERSEDED {
+			for id := range r.Superseders {
+				// Unknown superseders are kept: there is no date to exclude them by.
+				if superseder, ok := s.records[id]; !ok || existing[superseder.ID] {
+					superseders.Append(id)
+				}
+			}
+		}
+		r.Superseders = superseders
+
+		supersedes := make(Set[string])
+		for id := range r.Supersedes {
+			if superseded, ok := s.records[id]; !ok || superseded.StatusAt(at) == SUPERSEDED {
+				supersedes.Append(id)
+			}
+		}
+		r.Supersedes = supersedes
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package records
+
+import (
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestStatusAt(t *testing.T) {
+	r := AdrData{Status: DEPRECATED, History: []HistoryEntry{
+		{Status: PROPOSED, Date: day(2)},
+		{Status: ACCEPTED, Date: day(5)},
+		{Status: DEPRECATED, Date: day(9)},
+	}}
+	tests := []struct {
+		at   time.Time
+		want AdrStatus
+	}{
+		{day(1), UNKNOWN},
+		{day(2), PROPOSED},
+		{day(6), ACCEPTED},
+		{day(20), DEPRECATED},
+	}
+	for _, tt := range tests {
+		if got := r.StatusAt(tt.at); got != tt.want {
+			t.Errorf("StatusAt(%s) = %q, want %q", tt.at, got, tt.want)
+		}
+	}
+	if got := (AdrData{Status: ACCEPTED}).StatusAt(day(1)); got != ACCEPTED {
+		t.Errorf("without history StatusAt = %q, want the current status", got)
+	}
+}
+
+func TestGetRecordsAsOf(t *testing.T) {
+	superseders := make(Set[string])
+	superseders.Append("new")
+	old := AdrData{ID: "old", CreationDate: day(1), Status: SUPERSEDED, Superseders: superseders, History: []HistoryEntry{
+		{Status: ACCEPTED, Date: day(1)},
+		{Status: SUPERSEDED, Date: day(10)},
+	}}
+	newer := AdrData{ID: "new", CreationDate: day(8), Status: ACCEPTED, History: []HistoryEntry{{Status: ACCEPTED, Date: day(8)}}}
+	svc := Service{
+		records: map[string]AdrData{"old": old, "new": newer},
+		ids:     []string{"old", "new"},
+	}
+
+	before := svc.GetRecordsAsOf(day(5))
+	if len(before) != 1 || before[0].ID != "old" {
+		t.Fatalf("as of day 5 = %+v, want only the old record", before)
+	}
+	if before[0].Status != ACCEPTED || len(before[0].Superseders) != 0 || len(before[0].History) != 1 {
+		t.Errorf("old record as of day 5 = %+v, want accepted without superseders", before[0])
+	}
+
+	between := svc.GetRecordsAsOf(day(9))
+	if len(between) != 2 || between[0].Status != ACCEPTED || len(between[0].Superseders) != 0 {
+		t.Errorf("as of day 9 = %+v, want both records, old one not superseded yet", between)
+	}
+
+	after := svc.GetRecordsAsOf(day(11))
+	if after[0].Status != SUPERSEDED || !after[0].Superseders["new"] {
+		t.Errorf("old record as of day 11 = %+v, want superseded by new", after[0])
+	}
+}
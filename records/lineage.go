@@ -0,0 +1,172 @@
+package records
+
+import "slices"
+
+// LineageEntry is a record of a lineage, with the records of the lineage it
+// supersedes and is superseded by, by reference (see Ref), in lineage order.
+type LineageEntry struct {
+	Ref          string
+	Record       AdrData
+	Supersedes   []string
+	SupersededBy []string
+}
+
+// Lineage is the supersession history a record belongs to: the records it
+// supersedes or is superseded by, theirs, and so on. Each record comes before
+// the records superseding it.
+type Lineage []LineageEntry
+
+// Lineage returns the lineage of a record, following the supersessions stored
+// on either side of each pair, across collections. References to records that
+// do not exist are left out. Records superseding each other in a loop make it
+// fail with a *CycleError.
+func (s *Service) Lineage(record AdrData) (Lineage, error) {
+	nodes := map[string]AdrData{}
+	var order []string
+	supersedes := map[string]Set[string]{}
+	supersededBy := map[string]Set[string]{}
+	node := func(r AdrData) string {
+		ref := s.Ref(r)
+		if _, ok := nodes[ref]; !ok {
+			nodes[ref] = r
+			order = append(order, ref)
+		}
+		return ref
+	}
+	link := func(superseded, superseder string) {
+		to := supersededBy[superseded]
+		to.Append(superseder)
+		supersededBy[superseded] = to
+		from := supersedes[superseder]
+		from.Append(superseded)
+		supersedes[superseder] = from
+	}
+	// collect adds the supersessions a record stores, its references being
+	// resolved by the service of its collection.
+	collect := func(owner *Service, r AdrData) {
+		ref := node(r)
+		for _, other := range r.Superseders.ToSlice() {
+			if superseder, ok := owner.lookupRef(other); ok {
+				link(ref, node(superseder))
+			}
+		}
+		for _, other := range r.Supersedes.ToSlice() {
+			if superseded, ok := owner.lookupRef(other); ok {
+				link(node(superseded), ref)
+			}
+		}
+	}
+
+	for _, r := range s.GetRecords() {
+		collect(s, r)
+	}
+	start := node(record)
+	lineage := map[string]bool{start: true}
+	collected := map[string]bool{}
+	for queue := []string{start}; len(queue) > 0; queue = queue[1:] {
+		ref := queue[0]
+		if r := nodes[ref]; r.Collection != s.collection && !collected[ref] {
+			owner, err := s.For(r)
+			if err != nil {
+				return nil, err
+			}
+			collect(owner, r)
+			collected[ref] = true
+		}
+		for _, next := range slices.Concat(supersedes[ref].ToSlice(), supersededBy[ref].ToSlice()) {
+			if !lineage[next] {
+				lineage[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Records are taken in index order, each once the ones it supersedes are.
+	refs := slices.DeleteFunc(order, func(ref string) bool { return !lineage[ref] })
+	var out Lineage
+	done := map[string]bool{}
+	for len(refs) > 0 {
+		i := slices.IndexFunc(refs, func(ref string) bool {
+			for superseded := range supersedes[ref] {
+				if !done[superseded] {
+					return false
+				}
+			}
+			return true
+		})
+		if i < 0 {
+			return nil, &CycleError{Refs: loop(refs, supersededBy)}
+		}
+		ref := refs[i]
+		refs = slices.Delete(refs, i, i+1)
+		done[ref] = true
+		out = append(out, LineageEntry{
+			Ref:          ref,
+			Record:       nodes[ref],
+			Supersedes:   supersedes[ref].ToSlice(),
+			SupersededBy: supersededBy[ref].ToSlice(),
+		})
+	}
+	// The references of each entry follow the lineage order too.
+	position := make(map[string]int, len(out))
+	for i, e := range out {
+		position[e.Ref] = i
+	}
+	byPosition := func(a, b string) int { return position[a] - position[b] }
+	for _, e := range out {
+		slices.SortFunc(e.Supersedes, byPosition)
+		slices.SortFunc(e.SupersededBy, byPosition)
+	}
+	return out, nil
+}
+
+// loop returns, among records that cannot be ordered, the ones on a loop: the
+// records leading to a loop without being on one are dropped.
+func loop(refs []string, supersededBy map[string]Set[string]) []string {
+	left := Set[string]{}
+	left.Append(refs...)
+	for changed := true; changed; {
+		changed = false
+		for ref := range left {
+			if !slices.ContainsFunc(supersededBy[ref].ToSlice(), func(superseder string) bool { return left[superseder] }) {
+				left.Remove(ref)
+				changed = true
+			}
+		}
+	}
+	return left.ToSlice()
+}
+
+// Current returns the entries in force from a record of the lineage on: the
+// records superseding it, directly or not, that nothing supersedes (the record
+// itself when nothing supersedes it).
+func (l Lineage) Current(ref string) []LineageEntry {
+	reached := map[string]bool{ref: true}
+	var current []LineageEntry
+	for _, e := range l {
+		if !reached[e.Ref] {
+			continue
+		}
+		for _, superseder := range e.SupersededBy {
+			reached[superseder] = true
+		}
+		if len(e.SupersededBy) == 0 {
+			current = append(current, e)
+		}
+	}
+	return current
+}
+
+// Origins returns the entries of the lineage that supersede nothing.
+func (l Lineage) Origins() []LineageEntry {
+	return slices.DeleteFunc(slices.Clone(l), func(e LineageEntry) bool { return len(e.Supersedes) > 0 })
+}
+
+// Get returns the entry of a record of the lineage.
+func (l Lineage) Get(ref string) (LineageEntry, bool) {
+	i := slices.IndexFunc(l, func(e LineageEntry) bool { return e.Ref == ref })
+	if i < 0 {
+		return LineageEntry{}, false
+	}
+	return l[i], true
+}
@@ -0,0 +1,78 @@
+package records
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestLineage(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	create := func(id string, superseders, supersedes Set[string]) AdrData {
+		t.Helper()
+		r, err := svc.CreateRecord(id, AdrData{ID: id, Status: ACCEPTED, Superseders: superseders, Supersedes: supersedes}, "")
+		if err != nil {
+			t.Fatalf("CreateRecord(%s): %v", id, err)
+		}
+		return r
+	}
+	// a is superseded by b, which two records replaced: c (stored on b only) and
+	// d (stored on d only). e is unrelated.
+	a := create("a", Set[string]{"b": true}, nil)
+	create("b", Set[string]{"c": true}, Set[string]{"a": true})
+	create("c", nil, nil)
+	d := create("d", nil, Set[string]{"b": true})
+	create("e", nil, nil)
+
+	lineage, err := svc.Lineage(d)
+	if err != nil {
+		t.Fatalf("Lineage: %v", err)
+	}
+	var refs []string
+	for _, e := range lineage {
+		refs = append(refs, e.Ref)
+	}
+	if want := []string{"a", "b", "c", "d"}; !slices.Equal(refs, want) {
+		t.Errorf("lineage = %v, want %v", refs, want)
+	}
+	if b, _ := lineage.Get("b"); !slices.Equal(b.SupersededBy, []string{"c", "d"}) || !slices.Equal(b.Supersedes, []string{"a"}) {
+		t.Errorf("b = %+v, want superseded by c and d, superseding a", b)
+	}
+
+	tests := []struct {
+		ref  string
+		want []string
+	}{
+		{"a", []string{"c", "d"}},
+		{"c", []string{"c"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, e := range lineage.Current(tt.ref) {
+			got = append(got, e.Ref)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Current(%s) = %v, want %v", tt.ref, got, tt.want)
+		}
+	}
+
+	// A loop has no origin.
+	create("f", nil, Set[string]{"a": true})
+	a.Supersedes = Set[string]{"f": true}
+	if err := svc.UpdateRecord(a); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	var cycle *CycleError
+	if _, err := svc.Lineage(a); !errors.As(err, &cycle) || !errors.Is(err, ErrCycle) || !slices.Equal(cycle.Refs, []string{"a", "f"}) {
+		t.Errorf("Lineage error = %v, want a cycle between a and f", err)
+	}
+}
@@ -0,0 +1,166 @@
+package records
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// linkRegex matches the targets of markdown links to markdown files: inline links
+// "[x](file.md)" and reference definitions "[x]: file.md", optionally followed by
+// an anchor.
+var linkRegex = regexp.MustCompile(`(?m)(\]\(\s*|\]:[ \t]*)([^\s()#<>]+\.md)([#)\s]|$)`)
+
+// writeRewritten writes a file whose links were rewritten. A record's last
+// update date is set and its front matter written again, like any change to a
+// record, so the index stays current. Archived records are read-only: only their
+// body changes, their front-matter and dates are left as they are. Files that
+// are not records are written as they are.
+func (s Service) writeRewritten(name, content string) error {
+	for id, r := range s.records {
+		if r.Name != name {
+			continue
+		}
+		adr, diag := parseContent(name, []byte(content))
+		if diag != nil {
+			break
+		}
+		adr.Collection, adr.Project = r.Collection, r.Project
+		adr.Category, adr.Draft, adr.Archived = r.Category, r.Draft, r.Archived
+		if r.Archived {
+			if err := s.storage.WriteFile(s.file(name), []byte(content)); err != nil {
+				return err
+			}
+			s.records[id] = adr
+			return nil
+		}
+		adr.LastUpdateDate = time.Now()
+		header, err := MarshalYAML(adr)
+		if err != nil {
+			return err
+		}
+		sum, err := s.writeRecord(name, string(header), adr.Body)
+		if err != nil {
+			return err
+		}
+		adr.checksum = sum
+		s.records[id] = adr
+		return nil
+	}
+	return s.storage.WriteFile(s.file(name), []byte(content))
+}
+
+// markdownFiles returns the names, relative to the ADR directory, of the
+// markdown files of the ADR directory and its subdirectories, and of the drafts
+// and archive directories. Hidden files and directories are left out.
+func (s Service) markdownFiles() ([]string, error) {
+	seen := map[string]bool{}
+	var names []string
+	for _, root := range []string{s.adrsPath, s.draftsPath, s.archivePath} {
+		err := fs.WalkDir(s.storage, root, func(p string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root && errors.Is(err, fs.ErrNotExist) {
+					return fs.SkipAll
+				}
+				return err
+			}
+			if strings.HasPrefix(entry.Name(), ".") && p != root {
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() || path.Ext(p) != ".md" {
+				return nil
+			}
+			name, err := relPath(s.adrsPath, p)
+			if err != nil {
+				return err
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// rewriteLinks updates the relative markdown links of every file of the ADR
+// directory (the records, a generated index, the drafts and the archive) after
+// files were renamed or moved: links to a renamed file point to its new name, and
+// the links of a moved file are adjusted to its new directory. Renames are
+// applied in a single pass, so chained renames (a→b, b→c) are not applied twice.
+// The records it changes get a new last update date, except archived ones
+// (see writeRewritten). Names are relative to the
+// ADR directory. It returns the files it changed.
+func (s Service) rewriteLinks(renames map[string]string) ([]string, error) {
+	if len(renames) == 0 {
+		return nil, nil
+	}
+	previous := make(map[string]string, len(renames))
+	for old, renamed := range renames {
+		previous[renamed] = old
+	}
+
+	names, err := s.markdownFiles()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, name := range names {
+		p := s.file(name)
+		b, err := s.storage.ReadFile(p)
+		if err != nil {
+			return changed, err
+		}
+		dir, oldDir := path.Dir(name), path.Dir(name)
+		if old, moved := previous[name]; moved {
+			oldDir = path.Dir(old)
+		}
+		out := linkRegex.ReplaceAllStringFunc(string(b), func(match string) string {
+			m := linkRegex.FindStringSubmatch(match)
+			target := m[2]
+			if strings.Contains(target, ":") || path.IsAbs(target) {
+				return match // a URL or an absolute path
+			}
+			resolved := path.Join(oldDir, target)
+			if renamed, ok := renames[resolved]; ok {
+				resolved = renamed
+			} else if oldDir == dir {
+				return match
+			}
+			link := relativeLink(dir, resolved)
+			if strings.HasPrefix(target, "./") && !strings.HasPrefix(link, "../") {
+				link = "./" + link
+			}
+			return m[1] + link + m[3]
+		})
+		if out == string(b) {
+			continue
+		}
+		if err := s.writeRewritten(name, out); err != nil {
+			return changed, err
+		}
+		changed = append(changed, name)
+	}
+	return changed, nil
+}
+
+// relativeLink returns the link from a file in dir to target, both relative to
+// the ADR directory.
+func relativeLink(dir, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(target))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,92 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	dir := t.TempDir()
+	content := `See [a](001_a.md), [a again](./001_a.md#context) and [b][b].
+Not a link: 001_a.md, nor [x](001_a.mdx).
+
+[b]: 002_b.md
+`
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := Service{storage: NewOSStorage(dir), adrsPath: ".", records: map[string]AdrData{}}
+	// Chained renames are applied once: 001_a → 002_a, 002_b → 003_b.
+	changed, err := svc.rewriteLinks(map[string]string{"001_a.md": "002_a.md", "002_b.md": "003_b.md"})
+	if err != nil {
+		t.Fatalf("rewriteLinks: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != "README.md" {
+		t.Errorf("changed = %v, want [README.md]", changed)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "README.md"))
+	want := `See [a](002_a.md), [a again](./002_a.md#context) and [b][b].
+Not a link: 001_a.md, nor [x](001_a.mdx).
+
+[b]: 003_b.md
+`
+	if string(b) != want {
+		t.Errorf("rewritten content:\n%s\nwant:\n%s", b, want)
+	}
+}
+
+func TestRewriteLinksUpdatesRecords(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	record := `---
+id: b
+title: B
+status: accepted
+creation_date: 2026-01-02T03:04:05Z
+last_update_date: 2026-01-02T03:04:05Z
+---
+
+See [a](001_a.md).
+`
+	if err := storage.WriteFile("adrs/002_b.md", []byte(record)); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.MkdirAll("adrs/archive"); err != nil {
+		t.Fatal(err)
+	}
+	archived := strings.NewReplacer("id: b", "id: c", "(001_a.md)", "(../001_a.md)").Replace(record)
+	if err := storage.WriteFile("adrs/archive/002_c.md", []byte(archived)); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	before, _ := svc.GetRecord("b")
+	if _, err := svc.rewriteLinks(map[string]string{"001_a.md": "003_a.md"}); err != nil {
+		t.Fatalf("rewriteLinks: %v", err)
+	}
+	r, _ := svc.GetRecord("b")
+	if !r.LastUpdateDate.After(before.LastUpdateDate) {
+		t.Errorf("last update date = %v, want it updated", r.LastUpdateDate)
+	}
+	if !strings.Contains(r.Body, "[a](003_a.md)") {
+		t.Errorf("body = %q, want the link rewritten", r.Body)
+	}
+	// Archived records only get their body rewritten.
+	b, _ := storage.ReadFile("adrs/archive/002_c.md")
+	if want := strings.Replace(archived, "(../001_a.md)", "(../003_a.md)", 1); string(b) != want {
+		t.Errorf("archived record:\n%s\nwant:\n%s", b, want)
+	}
+	// The index follows the file: updating the record is no conflict.
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Errorf("UpdateRecord after rewrite: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package records
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// lockFile is created in the ADR directory while a process allocates or
+	// writes records; it does not look like a record so indexing ignores it.
+	lockFile = ".adr.lock"
+	// lockTimeout bounds how long a process waits for another one to finish.
+	lockTimeout = 10 * time.Second
+	// staleLockAge is the age past which a lock is considered abandoned (e.g.
+	// left behind by a crashed process) and is broken.
+	staleLockAge   = time.Minute
+	lockRetryDelay = 20 * time.Millisecond
+)
+
+// lockDir takes an exclusive, advisory lock on an ADR directory. It relies on
+// exclusive file creation rather than flock so it behaves the same on every
+// platform. The returned function releases the lock. The lock file holds a
+// token of its own, so that releasing a lock never removes another one.
+func lockDir(dir string) (func(), error) {
+	path := filepath.Join(dir, lockFile)
+	token, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err := f.WriteString(token)
+			if err = errors.Join(err, f.Close()); err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return func() { releaseLock(path, token) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if breakStaleLock(path) {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %q (remove it if no other adr command is running)", path)
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// lockToken returns the content of a new lock file: the process ID, for whoever
+// finds the lock, and random bytes telling the locks of the process apart.
+func lockToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %x\n", os.Getpid(), b), nil
+}
+
+// releaseLock removes a lock file if it still holds the token of the lock: a
+// lock broken as stale, and since taken by another process, is left alone.
+func releaseLock(path, token string) {
+	if b, err := os.ReadFile(path); err == nil && string(b) == token {
+		os.Remove(path)
+	}
+}
+
+// breakStaleLock removes a lock file older than staleLockAge and reports
+// whether it did. The file is first renamed aside, which only one of the
+// processes finding it stale manages to do. When the file renamed turns out to
+// be a fresh lock, taken by another process after the stale one was broken, it
+// is put back.
+func breakStaleLock(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) <= staleLockAge {
+		return false
+	}
+	aside := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		return false
+	}
+	defer os.Remove(aside)
+	if info, err := os.Stat(aside); err == nil && time.Since(info.ModTime()) <= staleLockAge {
+		// Unless the lock was taken again in the meantime, which makes linking fail.
+		os.Link(aside, path)
+		return false
+	}
+	return true
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so a crash never leaves a half-written file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// Removing the temporary file is a no-op once it has been renamed.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
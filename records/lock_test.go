@@ -0,0 +1,47 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, lockFile)
+
+	// Releasing a lock broken as stale leaves the lock taken since alone.
+	unlock, err := lockDir(dir)
+	if err != nil {
+		t.Fatalf("lockDir: %v", err)
+	}
+	stale := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(path, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	unlockAgain, err := lockDir(dir)
+	if err != nil {
+		t.Fatalf("lockDir over a stale lock: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the lock taken after the stale one was released: %v", err)
+	}
+	unlockAgain()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock not released: %v", err)
+	}
+
+	// A fresh lock is not broken.
+	if err := os.WriteFile(path, []byte("1 other\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if breakStaleLock(path) {
+		t.Error("a fresh lock should not be broken")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("files left in the directory: %v", entries)
+	}
+}
@@ -0,0 +1,332 @@
+package records
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemStorage is a Storage holding the files of a project in memory, for tests
+// and dry runs. It is safe for concurrent use.
+type MemStorage struct {
+	mu    sync.RWMutex
+	files memFiles
+	locks map[string]*sync.Mutex
+}
+
+// NewMemStorage returns an empty MemStorage.
+func NewMemStorage() *MemStorage {
+	return &MemStorage{files: memFiles{}, locks: map[string]*sync.Mutex{}}
+}
+
+// CopyToMemory returns a MemStorage holding a copy of the files of fsys (e.g.
+// os.DirFS of a project directory), to try changes on without touching them.
+func CopyToMemory(fsys fs.FS) (*MemStorage, error) {
+	m := NewMemStorage()
+	err := fs.WalkDir(fsys, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || p == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		file := &memFile{mode: info.Mode(), modTime: info.ModTime()}
+		if !entry.IsDir() {
+			if file.data, err = fs.ReadFile(fsys, p); err != nil {
+				return err
+			}
+		}
+		m.files[p] = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *MemStorage) Open(name string) (fs.File, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Open(name)
+}
+
+func (m *MemStorage) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.Stat(name)
+}
+
+func (m *MemStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.ReadDir(name)
+}
+
+func (m *MemStorage) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.files.ReadFile(name)
+}
+
+func (m *MemStorage) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkCreate("write", name); err != nil {
+		return err
+	}
+	if m.isDir(name) {
+		return &fs.PathError{Op: "write", Path: name, Err: errors.New("is a directory")}
+	}
+	m.files[name] = &memFile{data: slices.Clone(data), mode: 0o644, modTime: time.Now()}
+	return nil
+}
+
+func (m *MemStorage) CreateExclusive(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkCreate("open", name); err != nil {
+		return err
+	}
+	if m.exists(name) {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	m.files[name] = &memFile{mode: 0o644, modTime: time.Now()}
+	return nil
+}
+
+func (m *MemStorage) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !fs.ValidPath(oldName) || !m.exists(oldName) {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrNotExist}
+	}
+	if err := m.checkCreate("rename", newName); err != nil {
+		return err
+	}
+	if m.isDir(oldName) {
+		if m.exists(newName) {
+			return &fs.PathError{Op: "rename", Path: newName, Err: fs.ErrExist}
+		}
+		moved := memFiles{}
+		for name, file := range m.files {
+			if rest, ok := strings.CutPrefix(name, oldName+"/"); ok {
+				delete(m.files, name)
+				moved[path.Join(newName, rest)] = file
+			}
+		}
+		maps.Copy(m.files, moved)
+	}
+	if file, ok := m.files[oldName]; ok {
+		delete(m.files, oldName)
+		m.files[newName] = file
+	}
+	return nil
+}
+
+func (m *MemStorage) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !fs.ValidPath(name) || !m.exists(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	for other := range m.files {
+		if strings.HasPrefix(other, name+"/") {
+			return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *MemStorage) MkdirAll(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !fs.ValidPath(name) {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	for dir := name; dir != "."; dir = path.Dir(dir) {
+		if m.isDir(dir) {
+			continue
+		}
+		if m.exists(dir) {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: errors.New("not a directory")}
+		}
+		m.files[dir] = &memFile{mode: fs.ModeDir | 0o755, modTime: time.Now()}
+	}
+	return nil
+}
+
+// Lock takes a lock on a directory that only holds within the process.
+func (m *MemStorage) Lock(dir string) (func(), error) {
+	m.mu.Lock()
+	l, ok := m.locks[dir]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[dir] = l
+	}
+	m.mu.Unlock()
+	l.Lock()
+	return l.Unlock, nil
+}
+
+// checkCreate returns an error when a file cannot be created: its name is
+// invalid or its directory does not exist.
+func (m *MemStorage) checkCreate(op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if !m.isDir(path.Dir(name)) {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+func (m *MemStorage) exists(name string) bool {
+	_, err := m.files.Stat(name)
+	return err == nil
+}
+
+func (m *MemStorage) isDir(name string) bool {
+	info, err := m.files.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// memFile is a file or a directory of a MemStorage.
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// memFiles maps the names of the files and directories of a MemStorage to
+// them. The root directory (".") is implicit.
+type memFiles map[string]*memFile
+
+// memRoot is the implicit root directory of memFiles.
+var memRoot = &memFile{mode: fs.ModeDir | 0o755}
+
+func (f memFiles) lookup(op, name string) (*memFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return memRoot, nil
+	}
+	file, ok := f[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return file, nil
+}
+
+func (f memFiles) Open(name string) (fs.File, error) {
+	file, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	info := memFileInfo{name: path.Base(name), file: file}
+	if !file.mode.IsDir() {
+		return &openMemFile{info: info, Reader: bytes.NewReader(file.data)}, nil
+	}
+	entries, err := f.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	return &openMemDir{info: info, entries: entries}, nil
+}
+
+func (f memFiles) Stat(name string) (fs.FileInfo, error) {
+	file, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{name: path.Base(name), file: file}, nil
+}
+
+func (f memFiles) ReadDir(name string) ([]fs.DirEntry, error) {
+	dir, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	var entries []fs.DirEntry
+	for other, file := range f {
+		if path.Dir(other) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(memFileInfo{name: path.Base(other), file: file}))
+		}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+func (f memFiles) ReadFile(name string) ([]byte, error) {
+	file, err := f.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if file.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return slices.Clone(file.data), nil
+}
+
+// memFileInfo describes a memFile, under the last element of its name.
+type memFileInfo struct {
+	name string
+	file *memFile
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i memFileInfo) Mode() fs.FileMode  { return i.file.mode }
+func (i memFileInfo) ModTime() time.Time { return i.file.modTime }
+func (i memFileInfo) IsDir() bool        { return i.file.mode.IsDir() }
+func (i memFileInfo) Sys() any           { return nil }
+
+// openMemFile is a memFile opened for reading.
+type openMemFile struct {
+	info memFileInfo
+	*bytes.Reader
+}
+
+func (f *openMemFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openMemFile) Close() error               { return nil }
+
+// openMemDir is a directory of memFiles opened for listing.
+type openMemDir struct {
+	info    memFileInfo
+	entries []fs.DirEntry
+}
+
+func (d *openMemDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openMemDir) Close() error               { return nil }
+
+func (d *openMemDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *openMemDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+// skippedProjectDirs are directories that never hold projects of their own but
+// may hold many configuration files (dependencies).
+var skippedProjectDirs = []string{"node_modules", "vendor"}
+
+// DiscoverProjects finds every configuration file below root (root included)
+// and returns a service per ADR log they declare: one per project, or one per
+// collection of the projects that declare collections. Each record's Project is
+// the directory of its configuration file relative to root ("." for root
+// itself). Hidden directories and dependency directories are not searched.
+// Projects whose configuration or records cannot be loaded are left out and
+// reported as diagnostics, so one broken project does not hide the others.
+func DiscoverProjects(root string, opts ...Option) ([]*Service, []Diagnostic, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var configs []string
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if p != root && errors.Is(err, fs.ErrPermission) {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if p != root && (strings.HasPrefix(entry.Name(), ".") || slices.Contains(skippedProjectDirs, entry.Name())) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.Name() == cs.ConfigurationFile {
+			configs = append(configs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var services []*Service
+	var diagnostics []Diagnostic
+	for _, config := range configs {
+		project, err := filepath.Rel(root, filepath.Dir(config))
+		if err != nil {
+			return nil, nil, err
+		}
+		project = filepath.ToSlash(project)
+		found, err := openProject(config, project, o)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{
+				File:    path.Join(project, cs.ConfigurationFile),
+				Kind:    DiagnosticBadConfig,
+				Message: err.Error(),
+				Project: project,
+			})
+			continue
+		}
+		services = append(services, found...)
+	}
+	slices.SortStableFunc(services, func(a, b *Service) int { return strings.Compare(a.project, b.project) })
+	return services, diagnostics, nil
+}
+
+// openProject returns a service per ADR log declared by a configuration file.
+func openProject(path, project string, o options) ([]*Service, error) {
+	root, dir, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	names := collectionNames(root)
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	services := make([]*Service, 0, len(names))
+	for _, name := range names {
+		o.collection = name
+		s, err := newService(root, NewOSStorage(dir), o)
+		if err != nil {
+			if name != "" {
+				err = fmt.Errorf("collection %q: %w", name, err)
+			}
+			return nil, err
+		}
+		s.project = project
+		for id, r := range s.records {
+			r.Project = project
+			s.records[id] = r
+		}
+		for i := range s.diagnostics {
+			s.diagnostics[i].Project = project
+		}
+		services = append(services, s)
+	}
+	return services, nil
+}
+
+// Project returns the directory of the service's configuration file relative
+// to where it was discovered by DiscoverProjects ("" otherwise).
+func (s Service) Project() string {
+	return s.project
+}
@@ -0,0 +1,55 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiscoverProjects(t *testing.T) {
+	newTestProject(t)
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("adrs/001_root.md", "---\nid: root\ntitle: Root\nstatus: accepted\n---\n")
+	write("services/payments/.adrrc.yml", "directory: adrs\n")
+	write("services/payments/adrs/001_stripe.md", "---\nid: stripe\ntitle: Stripe\nstatus: accepted\n---\n")
+	write("services/orders/.adrrc.yml", "directory: adrs\ncollections:\n  api:\n    directory: api\n")
+	write("services/orders/adrs/001_split.md", "---\nid: split\ntitle: Split\nstatus: accepted\n---\n")
+	write("services/orders/api/001_rest.md", "---\nid: rest\ntitle: REST\nstatus: accepted\n---\n")
+	// Dependencies, hidden directories and broken projects are left out.
+	write("node_modules/lib/.adrrc.yml", "directory: .\n")
+	write(".cache/.adrrc.yml", "directory: .\n")
+	write("services/broken/.adrrc.yml", "directory: missing\n")
+
+	services, diagnostics, err := DiscoverProjects(".")
+	if err != nil {
+		t.Fatalf("DiscoverProjects: %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].File != "services/broken/.adrrc.yml" || diagnostics[0].Kind != DiagnosticBadConfig {
+		t.Errorf("DiscoverProjects() diagnostics = %+v, want the broken project", diagnostics)
+	}
+	var got []string
+	for _, s := range services {
+		for _, r := range s.GetRecords() {
+			if r.Project != s.Project() || r.Collection != s.Collection() {
+				t.Errorf("record %q is in %q/%q, its service in %q/%q", r.ID, r.Project, r.Collection, s.Project(), s.Collection())
+			}
+			got = append(got, s.Project()+"/"+s.Collection()+"/"+r.ID)
+		}
+	}
+	want := []string{".//root", "services/orders/default/split", "services/orders/api/rest", "services/payments//stripe"}
+	if !slices.Equal(got, want) {
+		t.Errorf("DiscoverProjects() records = %v, want %v", got, want)
+	}
+	if path := services[3].RecordPath(services[3].GetRecords()[0]); path != filepath.Join("services", "payments", "adrs", "001_stripe.md") {
+		t.Errorf("RecordPath() = %q", path)
+	}
+}
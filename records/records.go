@@ -1,34 +1,91 @@
 package records
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
+	"path"
 	"path/filepath"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
 	simpleSlug "github.com/gosimple/slug"
+	cs "github.com/gwleclerc/adr/constants"
 	"github.com/gwleclerc/adr/templates"
-	"github.com/gwleclerc/adr/utils"
 )
 
 type Service struct {
+	config          cs.Config // the whole configuration, to open other collections
+	others          *collectionServices
+	storage         Storage
+	cache           *indexCache // nil without a cache
+	dir             string      // the project directory, for storages on the local file system
+	collection      string
+	noCache         bool
+	metadataOnly    bool
+	project         string
 	records         map[string]AdrData
 	ids             []string
+	diagnostics     []Diagnostic
 	adrsPath        string
+	draftsPath      string
+	archivePath     string
+	perCategory     bool
 	templatesDir    string
 	defaultTemplate string
 	defaultAuthor   string
+	tocFile         string
+	tocGraph        bool
+	fields          []cs.FieldSpec
+	workflow        Workflow
+	relations       Relations
+	force           bool
+	actor           string
+	reason          string
 }
 
-func NewService() (*Service, error) {
-	cfg, dir, err := LoadConfig()
+// NewService indexes the records of the ADR directory configured in the nearest
+// configuration file (or of the collection chosen with WithCollection).
+func NewService(opts ...Option) (*Service, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	root, dir, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newService(root, NewOSStorage(dir), o)
+}
+
+// Open indexes the records of the project held by storage, as configured by
+// config (see ReadConfig), instead of looking for the nearest configuration
+// file.
+func Open(storage Storage, config cs.Config, opts ...Option) (*Service, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newService(config, storage, o)
+}
+
+// newService indexes the records of a collection of a configuration, stored
+// with the project's files. The top-level collection is selected by an empty name.
+func newService(root cs.Config, storage Storage, o options) (*Service, error) {
+	cfg, collection, err := selectCollection(root, o.collection)
 	if err != nil {
 		return nil, err
 	}
-	adrsPath := filepath.Join(dir, cfg.Directory)
-	info, err := os.Stat(adrsPath)
+	ctx := o.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	dir := osDir(storage)
+	adrsPath := cleanName(cfg.Directory)
+	info, err := storage.Stat(adrsPath)
 	if err != nil {
 		return nil, err
 	}
@@ -36,29 +93,106 @@ func NewService() (*Service, error) {
 		return nil, fmt.Errorf("%q should be a directory", adrsPath)
 	}
 
-	adrs, err := indexADRs(adrsPath)
+	fields, err := validateFieldSpecs(cfg.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	workflow, err := NewWorkflow(cfg.Statuses, cfg.Transitions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	relations, err := NewRelations(cfg.Relations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if cfg.Numbering != "" && cfg.Numbering != NumberingGlobal && cfg.Numbering != NumberingCategory {
+		return nil, fmt.Errorf("invalid configuration: numbering must be %q or %q, not %q", NumberingGlobal, NumberingCategory, cfg.Numbering)
+	}
+
+	draftsPath := path.Join(adrsPath, defaultDraftsDir)
+	if cfg.DraftsDir != "" {
+		draftsPath = cleanName(cfg.DraftsDir)
+	}
+	archivePath := path.Join(adrsPath, defaultArchiveDir)
+	if cfg.ArchiveDir != "" {
+		archivePath = cleanName(cfg.ArchiveDir)
+	}
+
+	var cache *indexCache
+	if !o.noCache {
+		cache = loadCache(storage)
+	}
+	adrs, diagnostics, err := indexTree(ctx, storage, adrsPath, adrsPath, true, cache, o.metadataOnly, draftsPath, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	drafts, draftDiagnostics, err := indexTree(ctx, storage, adrsPath, draftsPath, false, cache, o.metadataOnly)
+	if err != nil {
+		return nil, err
+	}
+	for _, adr := range drafts {
+		adr.Draft = true
+		adrs = append(adrs, adr)
+	}
+	archived, archiveDiagnostics, err := indexTree(ctx, storage, adrsPath, archivePath, true, cache, o.metadataOnly)
 	if err != nil {
 		return nil, err
 	}
+	cache.save(adrsPath, draftsPath, archivePath)
+	for _, adr := range archived {
+		adr.Archived = true
+		adrs = append(adrs, adr)
+	}
 	records := make(map[string]AdrData, len(adrs))
 	ids := make([]string, 0, len(adrs))
 	for _, adr := range adrs {
+		adr.Collection = collection
 		records[adr.ID] = adr
 		ids = append(ids, adr.ID)
 	}
+	diagnostics = slices.Concat(diagnostics, draftDiagnostics, archiveDiagnostics)
+	for i := range diagnostics {
+		diagnostics[i].Collection = collection
+	}
 
 	templatesDir := ""
 	if cfg.TemplatesDir != "" {
 		templatesDir = filepath.Join(dir, cfg.TemplatesDir)
 	}
-	return &Service{
+	tocFile := ""
+	if cfg.TOC != "" {
+		tocFile = cleanName(cfg.TOC)
+	}
+	s := &Service{
+		config:          root,
+		others:          o.others,
+		storage:         storage,
+		cache:           cache,
+		dir:             dir,
+		collection:      collection,
+		noCache:         o.noCache,
+		metadataOnly:    o.metadataOnly,
 		records:         records,
 		ids:             ids,
+		diagnostics:     diagnostics,
 		adrsPath:        adrsPath,
+		draftsPath:      draftsPath,
+		archivePath:     archivePath,
+		perCategory:     cfg.Numbering == NumberingCategory,
 		templatesDir:    templatesDir,
 		defaultTemplate: cfg.DefaultTemplate,
 		defaultAuthor:   cfg.DefaultAuthor,
-	}, nil
+		tocFile:         tocFile,
+		tocGraph:        cfg.TOCGraph,
+		fields:          fields,
+		workflow:        workflow,
+		relations:       relations,
+	}
+	if s.others == nil {
+		// The services of the other collections refer back to this one.
+		s.others = &collectionServices{opened: map[string]openedCollection{collection: {service: s}}}
+	}
+	return s, nil
 }
 
 // TemplatesDir returns the resolved custom templates directory ("" if unset).
@@ -76,9 +210,93 @@ func (s Service) DefaultAuthor() string {
 	return s.defaultAuthor
 }
 
-// RecordPath returns the absolute path of a record's file.
+// TOCPath returns the resolved path of the generated table of contents kept up
+// to date by the commands that rename or remove records ("" if unset).
+func (s Service) TOCPath() string {
+	if s.tocFile == "" {
+		return ""
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(s.tocFile))
+}
+
+// TOCGraph reports whether the table of contents embeds a graph of the records
+// ("toc_graph" in the configuration).
+func (s Service) TOCGraph() bool {
+	return s.tocGraph
+}
+
+// WriteTOC writes the table of contents configured with the "toc" key, if any.
+func (s Service) WriteTOC(toc string) error {
+	if s.tocFile == "" {
+		return nil
+	}
+	return s.storage.WriteFile(s.tocFile, []byte(toc))
+}
+
+// PerCategoryNumbering reports whether each category has its own sequence of
+// record numbers ("numbering: category" in the configuration).
+func (s Service) PerCategoryNumbering() bool {
+	return s.perCategory
+}
+
+// Fields returns the custom front-matter fields declared in the configuration.
+func (s Service) Fields() []cs.FieldSpec {
+	return s.fields
+}
+
+// Field returns the declaration of a custom field by name.
+func (s Service) Field(name string) (cs.FieldSpec, bool) {
+	for _, spec := range s.fields {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return cs.FieldSpec{}, false
+}
+
+// Workflow returns the statuses and transition rules of the project.
+func (s Service) Workflow() Workflow {
+	return s.workflow
+}
+
+// ParseStatus validates a raw value against the workflow of the service's
+// collection, which may declare statuses of its own, and returns the matching
+// AdrStatus.
+func (s Service) ParseStatus(v string) (AdrStatus, error) {
+	return s.workflow.ParseStatus(v)
+}
+
+// SetForce makes UpdateRecord overwrite records even when they changed on disk
+// since they were indexed, and apply status changes the workflow does not allow,
+// instead of failing with a ConflictError or a TransitionError.
+func (s *Service) SetForce(force bool) {
+	s.force = force
+	s.others.each(func(other *Service) { other.force = force })
+}
+
+// SetActor sets who is recorded in the history for status changes made by
+// UpdateRecord.
+func (s *Service) SetActor(actor string) {
+	s.actor = actor
+	s.others.each(func(other *Service) { other.actor = actor })
+}
+
+// SetReason sets the reason recorded in the history for status changes made by
+// UpdateRecord ("" for none).
+func (s *Service) SetReason(reason string) {
+	s.reason = reason
+	s.others.each(func(other *Service) { other.reason = reason })
+}
+
+// RecordPath returns the path of a record's file: on the local file system, or
+// in the storage for other storages.
 func (s Service) RecordPath(record AdrData) string {
-	return filepath.Join(s.adrsPath, record.Name)
+	return filepath.Join(s.dir, filepath.FromSlash(s.file(record.Name)))
+}
+
+// file returns the storage name of a file named relative to the ADR directory.
+func (s Service) file(name string) string {
+	return path.Join(s.adrsPath, name)
 }
 
 func (s Service) GetRecord(recordID string) (AdrData, bool) {
@@ -97,59 +315,283 @@ func (s Service) GetRecords() []AdrData {
 // CreateRecord writes a new record file. body is the markdown of the sections
 // (a template skeleton or a caller-provided, already-validated body); the record
 // envelope (front-matter, title and date) is added here.
-func (s Service) CreateRecord(title string, record AdrData, body string) (AdrData, error) {
-	prefix := fmt.Sprintf("%03d", 1)
-	for i := range s.ids {
-		recordID := s.ids[len(s.ids)-1-i]
-		previous := s.records[recordID]
-		if number := utils.GetRecordNumber(previous.Name); number != "" {
-			count, _ := strconv.Atoi(number)
-			prefix = fmt.Sprintf("%03d", count+1)
-			break
-		}
+//
+// The number is allocated under a directory lock from the files on disk (not the
+// possibly stale index), and the file is created exclusively, so concurrent runs
+// never share a number or overwrite each other's record. The record is written in
+// the subdirectory named by record.Category, if any. Drafts (record.Draft) get no
+// number: they are written to the drafts directory until promoted. The record is
+// added to the index.
+func (s *Service) CreateRecord(title string, record AdrData, body string) (AdrData, error) {
+	title = strings.TrimSpace(title)
+	slug := filenameSlug(title)
+	category, err := s.checkCategory(record.Category)
+	if err != nil {
+		return AdrData{}, err
 	}
 
-	title = strings.TrimSpace(title)
-	slug := strings.ReplaceAll(simpleSlug.Make(title), "-", "_")
-	filename := fmt.Sprintf("%s_%s.md", prefix, slug)
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return AdrData{}, err
+	}
+	defer unlock()
+
+	reserve := s.reserveFilename
+	if record.Draft {
+		reserve = s.reserveDraftFilename
+	}
+	filename, err := reserve(category, slug)
+	if err != nil {
+		return AdrData{}, err
+	}
 
 	date := time.Now()
 	// Store the human-readable title in the metadata; the slug lives only in the
 	// filename. The title is used verbatim so acronyms and casing are preserved.
 	record.Title = title
+	record.Collection = s.collection
+	record.Category = category
 	record.CreationDate = date
 	record.LastUpdateDate = date
 	record.Name = filename
+	// The creation is credited to the actor, like the later status changes, or
+	// to the author when no actor is set.
+	actor := s.actor
+	if actor == "" {
+		actor = record.Author
+	}
+	record.History = []HistoryEntry{{Status: record.Status, Date: date, Actor: actor}}
 
 	header, err := MarshalYAML(record)
 	if err != nil {
+		s.storage.Remove(s.file(filename))
 		return AdrData{}, err
 	}
 
 	fullBody := fmt.Sprintf("# %s\n\nDate: %s\n\n%s",
 		title, date.Format(time.RFC1123), strings.TrimRight(body, "\n"))
 
-	if err := s.writeRecord(filename, string(header), fullBody); err != nil {
+	sum, err := s.writeRecord(filename, string(header), fullBody)
+	if err != nil {
+		s.storage.Remove(s.file(filename))
 		return AdrData{}, err
 	}
+	record.Body, record.checksum = fullBody, sum
+	record.references, record.referencesKnown = referenceTargets(fullBody), true
+	record.Collection, record.Project = s.collection, s.project
+	s.add(record)
 	return record, nil
 }
 
+// add indexes a new record, after the records of its kind: numbered records
+// come first, then drafts, then archived records.
+func (s *Service) add(record AdrData) {
+	kind := func(r AdrData) int {
+		switch {
+		case r.Archived:
+			return 2
+		case r.Draft:
+			return 1
+		}
+		return 0
+	}
+	i := slices.IndexFunc(s.ids, func(id string) bool { return kind(s.records[id]) > kind(record) })
+	if i < 0 {
+		i = len(s.ids)
+	}
+	s.records[record.ID] = record
+	s.ids = slices.Insert(s.ids, i, record.ID)
+}
+
+// filenameSlug turns a title into the snake_case slug used in filenames.
+func filenameSlug(title string) string {
+	return strings.ReplaceAll(simpleSlug.Make(title), "-", "_")
+}
+
+// maxReserveAttempts bounds the search for a free number in reserveFilename.
+const maxReserveAttempts = 100
+
+// reserveFilename picks the next free number after the highest one on disk
+// (archived records keep theirs) and claims "NNN_<slug>.md" in the category's
+// directory with an exclusive create, moving on to the next number when that name
+// or number is already taken. The returned name is relative to the ADR directory.
+// The caller must hold the lock.
+func (s Service) reserveFilename(category, slug string) (string, error) {
+	used, highest, err := s.usedNumbers(category)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.MkdirAll(s.file(category)); err != nil {
+		return "", err
+	}
+
+	for number := highest + 1; number <= highest+maxReserveAttempts; number++ {
+		if used[number] {
+			continue
+		}
+		filename := path.Join(category, fmt.Sprintf("%03d_%s.md", number, slug))
+		err := s.storage.CreateExclusive(s.file(filename))
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return filename, nil
+	}
+	return "", fmt.Errorf("unable to find a free record number after %03d", highest)
+}
+
+// UpdateRecord rewrites a record from its in-memory snapshot, appending an entry
+// to its history when its status changed. Unless the service
+// is forced (see SetForce), it refuses to write when the status change is not
+// allowed by the workflow (a *TransitionError) or when the file changed on disk
+// since the snapshot was indexed (a *ConflictError). Archived records are
+// read-only (an *ArchivedError).
 func (s Service) UpdateRecord(record AdrData) error {
+	if record.Archived {
+		return &ArchivedError{ID: record.ID}
+	}
+	if previous, ok := s.records[record.ID]; ok {
+		if err := s.checkTransition(previous.Status, record.Status); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.checkUnchanged(&record); err != nil {
+		return err
+	}
+
 	record.LastUpdateDate = time.Now()
+	if previous, ok := s.records[record.ID]; ok {
+		s.trackStatus(&record, previous.Status)
+	}
 
 	header, err := MarshalYAML(record)
 	if err != nil {
 		return err
 	}
 
-	return s.writeRecord(record.Name, string(header), record.Body)
+	sum, err := s.writeRecord(record.Name, string(header), record.Body)
+	if err != nil {
+		return err
+	}
+	// Refresh the index so a later update of the same record in this run is not
+	// mistaken for a conflict with our own write.
+	record.checksum = sum
+	record.references, record.referencesKnown = referenceTargets(record.Body), true
+	if _, ok := s.records[record.ID]; ok {
+		s.records[record.ID] = record
+	}
+	return nil
 }
 
-func (s Service) writeRecord(filename, header, body string) error {
-	out, err := templates.RenderRecord(header, body)
+// checkTransition returns a *TransitionError when the workflow does not allow a
+// status change, unless the service is forced.
+func (s Service) checkTransition(from, to AdrStatus) error {
+	if s.force || s.workflow.CanTransition(from, to) {
+		return nil
+	}
+	return &TransitionError{From: from, To: to, Allowed: s.workflow.Next(from)}
+}
+
+// trackStatus appends an entry to the record's history when its status differs
+// from the previous one, dated with its last update.
+func (s Service) trackStatus(record *AdrData, previous AdrStatus) {
+	if record.Status == previous {
+		return
+	}
+	record.History = append(slices.Clip(record.History), HistoryEntry{
+		Status: record.Status,
+		Date:   record.LastUpdateDate,
+		Actor:  s.actor,
+		Reason: s.reason,
+	})
+}
+
+// Body returns the body of a record, reading it from the record file when the
+// record was indexed without it (see WithMetadataOnly).
+func (s Service) Body(record AdrData) (string, error) {
+	if !record.partial {
+		return record.Body, nil
+	}
+	_, body, err := s.readBody(record)
+	return body, err
+}
+
+// loadBody completes a record indexed without its body. Its front-matter must
+// not have changed on disk since it was indexed, unless the service is forced.
+func (s Service) loadBody(record *AdrData) error {
+	b, body, err := s.readBody(*record)
+	if err != nil {
+		return err
+	}
+	header, err := frontMatterPrefix(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	if !s.force && checksum(header) != record.checksum {
+		return &ConflictError{File: record.Name}
+	}
+	record.Body, record.checksum, record.partial = body, checksum(b), false
+	record.references, record.referencesKnown = referenceTargets(body), true
+	return nil
+}
+
+// Content returns the content of a record's file, front-matter included.
+func (s Service) Content(record AdrData) ([]byte, error) {
+	return s.storage.ReadFile(s.file(record.Name))
+}
+
+// readBody reads a record file, returning its content and body.
+func (s Service) readBody(record AdrData) ([]byte, string, error) {
+	b, err := s.Content(record)
+	if err != nil {
+		return nil, "", err
+	}
+	_, body, err := matter.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read yaml header from file %q: %w", record.Name, err)
+	}
+	return b, body, nil
+}
+
+// checkUnchanged returns a *ConflictError when the record's file changed on disk
+// since the record was indexed, unless the service is forced. Records indexed
+// without their body get it loaded, as rewriting them needs it: only their
+// front-matter has to be unchanged.
+func (s Service) checkUnchanged(record *AdrData) error {
+	if record.partial {
+		return s.loadBody(record)
+	}
+	if s.force || record.checksum == "" {
+		return nil
+	}
+	b, err := s.storage.ReadFile(s.file(record.Name))
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(s.adrsPath, filename), []byte(out), 0o644)
+	if checksum(b) != record.checksum {
+		return &ConflictError{File: record.Name}
+	}
+	return nil
+}
+
+// writeRecord renders and atomically writes a record, returning the checksum of
+// the written content.
+func (s Service) writeRecord(filename, header, body string) (string, error) {
+	out, err := templates.RenderRecord(header, body)
+	if err != nil {
+		return "", err
+	}
+	if err := s.storage.WriteFile(s.file(filename), []byte(out)); err != nil {
+		return "", err
+	}
+	return checksum([]byte(out)), nil
 }
@@ -0,0 +1,217 @@
+package records
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gwleclerc/adr/utils"
+)
+
+// referenceRegex matches the references to records written in bodies:
+// "[[ADR-004]]", "[[<ID>]]" or "[[payments:<ID>]]", optionally followed by the
+// text of the link ("[[ADR-004|the database choice]]").
+var referenceRegex = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]+))?\]\]`)
+
+// Reference is a reference to a record written in a body.
+type Reference struct {
+	// Ref designates the record: a number ("ADR-004") or an ID.
+	Ref string
+	// Text is the text of the link ("" for the title of the record).
+	Text string
+	// Line is the line of the body the reference is on, from 1.
+	Line int
+}
+
+// References returns the references to records written in a body ("[[ADR-004]]"),
+// leaving out the ones in code spans and fenced code blocks.
+func References(body string) []Reference {
+	var refs []Reference
+	replaceReferences(body, func(line int, ref, text, match string) string {
+		refs = append(refs, Reference{Ref: ref, Text: text, Line: line})
+		return match
+	})
+	return refs
+}
+
+// referenceTargets returns the distinct records a body refers to, as written
+// ("ADR-004"), in order of appearance. They are indexed with the records, so
+// finding backlinks does not read every body.
+func referenceTargets(body string) []string {
+	var targets []string
+	for _, r := range References(body) {
+		if !slices.Contains(targets, r.Ref) {
+			targets = append(targets, r.Ref)
+		}
+	}
+	return targets
+}
+
+// replaceReferences replaces the references of a body, outside code, by what
+// replace returns for them.
+func replaceReferences(body string, replace func(line int, ref, text, match string) string) string {
+	lines := strings.SplitAfter(body, "\n")
+	fence := ""
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		// Odd parts are code spans.
+		parts := strings.Split(line, "`")
+		for j := 0; j < len(parts); j += 2 {
+			parts[j] = referenceRegex.ReplaceAllStringFunc(parts[j], func(match string) string {
+				m := referenceRegex.FindStringSubmatch(match)
+				return replace(i+1, strings.TrimSpace(m[1]), strings.TrimSpace(m[2]), match)
+			})
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+	return strings.Join(lines, "")
+}
+
+// LookupReference returns the record a reference written in a body designates:
+// a record of the service's collection by number ("ADR-004", "4"), or a record
+// by ID, possibly of another collection ("payments:<ID>"). It returns an error
+// matching ErrNotFound when there is none, and an *AmbiguousError when a number
+// is used by several records.
+func (s Service) LookupReference(ref string) (AdrData, error) {
+	return s.referenceLookup()(ref)
+}
+
+// referenceLookup returns a LookupReference that numbers the records once, for
+// looking up many references.
+func (s Service) referenceLookup() func(ref string) (AdrData, error) {
+	numbers := map[int][]AdrData{}
+	for _, r := range s.GetRecords() {
+		if number := utils.GetRecordNumber(r.Name); number != "" && !r.Draft {
+			n, _ := strconv.Atoi(number)
+			numbers[n] = append(numbers[n], r)
+		}
+	}
+	return func(ref string) (AdrData, error) {
+		if match := numberRefRegex.FindStringSubmatch(ref); match != nil {
+			n, err := strconv.Atoi(match[1])
+			if err != nil {
+				n = -1
+			}
+			switch candidates := numbers[n]; len(candidates) {
+			case 0:
+			case 1:
+				return candidates[0], nil
+			default:
+				return AdrData{}, &AmbiguousError{Ref: ref, Candidates: candidates}
+			}
+		}
+		if r, ok := s.lookupRef(ref); ok {
+			return r, nil
+		}
+		return AdrData{}, &NotFoundError{Ref: ref}
+	}
+}
+
+// FileReferences returns the references to records written in the body of a
+// record, with their line in the record file. The file is read once, and not at
+// all when the indexed references say the body makes none.
+func (s Service) FileReferences(record AdrData) ([]Reference, error) {
+	if record.referencesKnown && len(record.references) == 0 {
+		return nil, nil
+	}
+	b, err := s.Content(record)
+	if err != nil {
+		return nil, err
+	}
+	header, err := frontMatterPrefix(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	refs := References(string(b[len(header):]))
+	offset := bytes.Count(header, []byte("\n"))
+	for i := range refs {
+		refs[i].Line += offset
+	}
+	return refs, nil
+}
+
+// ExpandReferences rewrites the references a record's body makes to records
+// ("[[ADR-004]]") into markdown links to their files, relative to the record's,
+// with their current title as text. References that designate no record are
+// left as they are.
+func (s *Service) ExpandReferences(record AdrData, body string) string {
+	dir := path.Dir(s.file(record.Name))
+	lookup := s.referenceLookup()
+	return replaceReferences(body, func(_ int, ref, text, match string) string {
+		target, err := lookup(ref)
+		if err != nil {
+			return match
+		}
+		owner, err := s.For(target)
+		if err != nil {
+			return match
+		}
+		if text == "" {
+			text = target.Title
+		}
+		return fmt.Sprintf("[%s](%s)", text, relativeLink(dir, owner.file(target.Name)))
+	})
+}
+
+// Backlinks returns the records of the service's collection whose body refers
+// to a record ("[[ADR-004]]"), in index order. The references are indexed with
+// the records: only the bodies never read before are (see indexReferences).
+func (s *Service) Backlinks(record AdrData) []AdrData {
+	s.indexReferences()
+	ref := s.Ref(record)
+	lookup := s.referenceLookup()
+	var backlinks []AdrData
+	for _, r := range s.GetRecords() {
+		if r.ID == record.ID && r.Collection == record.Collection {
+			continue
+		}
+		for _, reference := range r.references {
+			if target, err := lookup(reference); err == nil && s.Ref(target) == ref {
+				backlinks = append(backlinks, r)
+				break
+			}
+		}
+	}
+	return backlinks
+}
+
+// indexReferences reads the references of the records indexed from their
+// front-matter only, which do not know them yet, and keeps them in the index
+// cache: the next commands find them there as long as the files do not change.
+// Unreadable files are left out.
+func (s *Service) indexReferences() {
+	read := false
+	for _, id := range s.ids {
+		r := s.records[id]
+		if r.referencesKnown {
+			continue
+		}
+		b, err := s.Content(r)
+		if err != nil {
+			continue
+		}
+		header, err := frontMatterPrefix(bytes.NewReader(b))
+		if err != nil {
+			continue
+		}
+		r.references, r.referencesKnown = referenceTargets(string(b[len(header):])), true
+		s.records[id] = r
+		s.cache.setReferences(s.file(r.Name), b, header, r.references)
+		read = true
+	}
+	if read {
+		s.cache.save(s.adrsPath, s.draftsPath, s.archivePath)
+	}
+}
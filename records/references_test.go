@@ -0,0 +1,70 @@
+package records
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestReferences(t *testing.T) {
+	body := "See [[ADR-004]] and [[abc|the cache]].\n" +
+		"Not `[[in-code]]`, but [[ADR-7]].\n" +
+		"```\n[[fenced]]\n```\n" +
+		"[[last]]"
+	got := References(body)
+	want := []Reference{
+		{Ref: "ADR-004", Line: 1},
+		{Ref: "abc", Text: "the cache", Line: 1},
+		{Ref: "ADR-7", Line: 2},
+		{Ref: "last", Line: 6},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("References() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExpandReferences(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	db, _ := svc.CreateRecord("Use PostgreSQL", AdrData{ID: "db", Status: ACCEPTED}, "")
+	cache, _ := svc.CreateRecord("Cache reads", AdrData{ID: "cache", Status: ACCEPTED, Category: "backend"}, "Builds on [[ADR-1]] and [[ghost]].")
+	svc.CreateRecord("Unrelated", AdrData{ID: "other", Status: ACCEPTED}, "Nothing to see.")
+
+	body, _ := svc.Body(cache)
+	want := "Builds on [Use PostgreSQL](../001_use_postgresql.md) and [[ghost]]."
+	if got := svc.ExpandReferences(cache, body); !strings.Contains(got, want) {
+		t.Errorf("ExpandReferences() = %q, want %q", got, want)
+	}
+
+	if backlinks := svc.Backlinks(db); len(backlinks) != 1 || backlinks[0].ID != "cache" {
+		t.Errorf("Backlinks = %+v, want cache", backlinks)
+	}
+
+	content, _ := svc.Content(cache)
+	line := slices.IndexFunc(strings.Split(string(content), "\n"), func(l string) bool { return strings.Contains(l, "[[ADR-1]]") }) + 1
+	refs, err := svc.FileReferences(cache)
+	if err != nil || len(refs) != 2 || refs[0].Ref != "ADR-1" || refs[0].Line != line {
+		t.Errorf("FileReferences() = %+v, %v, want ADR-1 and ghost on line %d", refs, err, line)
+	}
+	// Records indexed without their body keep their references.
+	svc, err = Open(storage, cs.Config{Directory: "adrs"}, WithMetadataOnly())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if backlinks := svc.Backlinks(db); len(backlinks) != 1 || backlinks[0].ID != "cache" {
+		t.Errorf("Backlinks without bodies = %+v, want cache", backlinks)
+	}
+
+	if _, err := svc.LookupReference("ghost"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LookupReference(ghost) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,254 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+// Relation is a type of link between records: its name, read from the linking
+// record, and its inverse, read from the linked one. A symmetric relation is its
+// own inverse.
+type Relation struct {
+	Name        string
+	Inverse     string
+	Description string
+}
+
+// defaultRelations are the relation types every project has.
+var defaultRelations = []Relation{
+	{Name: "amends", Inverse: "amended-by", Description: "changes part of the linked decision"},
+	{Name: "refines", Inverse: "refined-by", Description: "details how the linked decision applies"},
+	{Name: "depends-on", Inverse: "required-by", Description: "only holds as long as the linked decision does"},
+	{Name: "conflicts-with", Inverse: "conflicts-with", Description: "cannot hold together with the linked decision"},
+	{Name: "relates-to", Inverse: "relates-to", Description: "is related to the linked decision"},
+}
+
+// Relations are the relation types of a project.
+type Relations []Relation
+
+// DefaultRelations returns the built-in relation types.
+func DefaultRelations() Relations {
+	return slices.Clone(defaultRelations)
+}
+
+// NewRelations builds the relation types declared in the configuration. They
+// are added to the built-in ones (a declared built-in name overrides it). Every
+// name, relation or inverse, must designate a single relation.
+func NewRelations(specs []cs.RelationSpec) (Relations, error) {
+	relations := DefaultRelations()
+	for _, spec := range specs {
+		if !validRelationName(spec.Name) || (spec.Inverse != "" && !validRelationName(spec.Inverse)) {
+			return nil, fmt.Errorf("invalid relation %q: names must be lowercase words joined by dashes", spec.Name)
+		}
+		r := Relation{Name: spec.Name, Inverse: spec.Inverse, Description: spec.Description}
+		if r.Inverse == "" {
+			r.Inverse = r.Name
+		}
+		relations = slices.DeleteFunc(relations, func(other Relation) bool { return other.Name == r.Name })
+		for _, other := range relations {
+			if other.Name == r.Inverse || other.Inverse == r.Name || other.Inverse == r.Inverse {
+				return nil, fmt.Errorf("invalid relation %q: its names clash with relation %q", r.Name, other.Name)
+			}
+		}
+		relations = append(relations, r)
+	}
+	return relations, nil
+}
+
+func validRelationName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return false
+	}
+	return strings.IndexFunc(name, func(r rune) bool { return (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' }) < 0
+}
+
+// Lookup returns the relation a name designates, oriented so that its Name is
+// the given one: looking up an inverse name ("amended-by") returns the relation
+// read the other way round ("amended-by", inverse "amends").
+func (rs Relations) Lookup(name string) (Relation, bool) {
+	for _, r := range rs {
+		switch name {
+		case r.Name:
+			return r, true
+		case r.Inverse:
+			return Relation{Name: r.Inverse, Inverse: r.Name, Description: r.Description}, true
+		}
+	}
+	return Relation{}, false
+}
+
+// Names returns every name a link can be made with, relations and inverses, in
+// alphabetical order.
+func (rs Relations) Names() []string {
+	names := map[string]bool{}
+	for _, r := range rs {
+		names[r.Name], names[r.Inverse] = true, true
+	}
+	return slices.Sorted(maps.Keys(names))
+}
+
+// RelationError reports a link made with a name that designates no relation.
+type RelationError struct {
+	Name    string
+	Allowed []string
+}
+
+func (e *RelationError) Error() string {
+	return fmt.Sprintf("unknown relation %q, allowed: %s", e.Name, strings.Join(e.Allowed, ", "))
+}
+
+// Relations returns the relation types of the project.
+func (s Service) Relations() Relations {
+	return s.relations
+}
+
+// Link links a record to a target: the link is stored on the record under the
+// relation's name, and on the target under its inverse, so both files tell about
+// it. Linking records that are already linked completes a missing side. The
+// target may belong to another collection. Archived records cannot be linked.
+func (s *Service) Link(record AdrData, name string, target AdrData) error {
+	return s.changeLink(record, name, target, true)
+}
+
+// Unlink removes a link between a record and a target, on both sides.
+func (s *Service) Unlink(record AdrData, name string, target AdrData) error {
+	return s.changeLink(record, name, target, false)
+}
+
+func (s *Service) changeLink(record AdrData, name string, target AdrData, add bool) error {
+	relation, ok := s.relations.Lookup(name)
+	if !ok {
+		return &RelationError{Name: name, Allowed: s.relations.Names()}
+	}
+	if record.ID == target.ID && record.Collection == target.Collection {
+		return errors.New("a record cannot be linked to itself")
+	}
+	for _, r := range []AdrData{record, target} {
+		if r.Archived {
+			return &ArchivedError{ID: r.ID}
+		}
+	}
+	owner, err := s.For(target)
+	if err != nil {
+		return err
+	}
+	change := (*AdrData).AddLink
+	if !add {
+		change = (*AdrData).RemoveLink
+	}
+	changed := change(&record, relation.Name, s.Ref(target))
+	if changed {
+		if err := s.UpdateRecord(record); err != nil {
+			return err
+		}
+	}
+	if change(&target, relation.Inverse, owner.Ref(record)) {
+		if err := owner.UpdateRecord(target); err != nil {
+			if changed {
+				if undoErr := s.revertLink(record.ID, relation.Name, s.Ref(target), add); undoErr != nil {
+					return fmt.Errorf("record %q: %w (record %q was changed alone: %v)", target.ID, err, record.ID, undoErr)
+				}
+			}
+			return fmt.Errorf("record %q: %w", target.ID, err)
+		}
+	}
+	return nil
+}
+
+// revertLink reverts the change changeLink made to a record when its target
+// could not be changed, so no link is left stored on one side only.
+func (s *Service) revertLink(id, name, ref string, added bool) error {
+	record, ok := s.GetRecord(id)
+	if !ok {
+		return fmt.Errorf("record %q: %w", id, ErrNotFound)
+	}
+	revert := (*AdrData).RemoveLink
+	if !added {
+		revert = (*AdrData).AddLink
+	}
+	if !revert(&record, name, ref) {
+		return nil
+	}
+	return s.UpdateRecord(record)
+}
+
+// AddLink adds a link to a record reference under a relation name, reporting
+// whether it was missing. The links are copied first, so other copies of the
+// record are unaffected.
+func (a *AdrData) AddLink(name, ref string) bool {
+	if a.Links[name][ref] {
+		return false
+	}
+	a.Links = cloneLinks(a.Links)
+	targets := a.Links[name]
+	targets.Append(ref)
+	a.Links[name] = targets
+	return true
+}
+
+// RemoveLink removes a link, reporting whether it was there. A relation left
+// without links is removed.
+func (a *AdrData) RemoveLink(name, ref string) bool {
+	if !a.Links[name][ref] {
+		return false
+	}
+	a.Links = cloneLinks(a.Links)
+	a.Links[name].Remove(ref)
+	if len(a.Links[name]) == 0 {
+		delete(a.Links, name)
+	}
+	return true
+}
+
+func cloneLinks(links map[string]Set[string]) map[string]Set[string] {
+	out := make(map[string]Set[string], len(links)+1)
+	for name, targets := range links {
+		out[name] = maps.Clone(targets)
+	}
+	return out
+}
+
+// InferredLinks returns the links to a record that only the linking records
+// store, keyed by the inverse relation name the record reads them with: the
+// links the record should hold back. The records of every collection of the
+// project are looked at, as links may cross collections; collections that
+// cannot be opened are skipped. Links with unknown relations are ignored.
+func (s *Service) InferredLinks(record AdrData) map[string][]string {
+	inferred := map[string][]string{}
+	for _, owner := range s.projectServices() {
+		ref := owner.Ref(record)
+		for _, other := range owner.GetRecords() {
+			for name, targets := range other.Links {
+				if !targets[ref] {
+					continue
+				}
+				relation, ok := owner.relations.Lookup(name)
+				if !ok || record.Links[relation.Inverse][s.Ref(other)] {
+					continue
+				}
+				inferred[relation.Inverse] = append(inferred[relation.Inverse], s.Ref(other))
+			}
+		}
+	}
+	return inferred
+}
+
+// projectServices returns the service, followed by the services of the other
+// collections of the project that could be opened.
+func (s *Service) projectServices() []*Service {
+	services := []*Service{s}
+	for _, name := range s.Collections() {
+		if name == s.collection {
+			continue
+		}
+		if other, err := s.OpenCollection(name); err == nil {
+			services = append(services, other)
+		}
+	}
+	return services
+}
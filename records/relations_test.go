@@ -0,0 +1,168 @@
+package records
+
+import (
+	"errors"
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestNewRelations(t *testing.T) {
+	tests := []struct {
+		name     string
+		specs    []cs.RelationSpec
+		lookup   string
+		want     Relation
+		wantFail bool
+	}{
+		{"built-in", nil, "amends", Relation{Name: "amends", Inverse: "amended-by"}, false},
+		{"built-in inverse", nil, "required-by", Relation{Name: "required-by", Inverse: "depends-on"}, false},
+		{"declared", []cs.RelationSpec{{Name: "implements", Inverse: "implemented-by"}}, "implemented-by", Relation{Name: "implemented-by", Inverse: "implements"}, false},
+		{"symmetric", []cs.RelationSpec{{Name: "pairs-with"}}, "pairs-with", Relation{Name: "pairs-with", Inverse: "pairs-with"}, false},
+		{"override", []cs.RelationSpec{{Name: "amends", Inverse: "amended-in"}}, "amended-in", Relation{Name: "amended-in", Inverse: "amends"}, false},
+		{"clash", []cs.RelationSpec{{Name: "extends", Inverse: "amended-by"}}, "", Relation{}, true},
+		{"invalid name", []cs.RelationSpec{{Name: "Depends On"}}, "", Relation{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relations, err := NewRelations(tt.specs)
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("NewRelations() error = %v, want failure: %v", err, tt.wantFail)
+			}
+			if tt.wantFail {
+				return
+			}
+			got, ok := relations.Lookup(tt.lookup)
+			got.Description = ""
+			if !ok || got != tt.want {
+				t.Errorf("Lookup(%q) = %+v, want %+v", tt.lookup, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceLink(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	a, _ := svc.CreateRecord("A", AdrData{ID: "a", Status: ACCEPTED}, "")
+	b, _ := svc.CreateRecord("B", AdrData{ID: "b", Status: ACCEPTED}, "")
+
+	if err := svc.Link(a, "amends", b); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	// The index and the files both hold the two sides.
+	reopened, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, s := range []*Service{svc, reopened} {
+		a, _ := s.GetRecord("a")
+		b, _ := s.GetRecord("b")
+		if !a.Links["amends"]["b"] || !b.Links["amended-by"]["a"] {
+			t.Errorf("links = %v and %v, want amends b and amended-by a", a.Links, b.Links)
+		}
+	}
+
+	// A link only stored on one side is inferred on the other.
+	a, _ = svc.GetRecord("a")
+	b, _ = svc.GetRecord("b")
+	oneSided := b
+	oneSided.RemoveLink("amended-by", "a")
+	if got := svc.InferredLinks(oneSided); len(got["amended-by"]) != 1 || got["amended-by"][0] != "a" {
+		t.Errorf("InferredLinks = %v, want amended-by a", got)
+	}
+
+	if err := svc.Unlink(b, "amended-by", a); err != nil {
+		t.Fatalf("Unlink: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if r, _ := svc.GetRecord(id); len(r.Links) != 0 {
+			t.Errorf("%s links = %v, want none", id, r.Links)
+		}
+	}
+
+	var relationErr *RelationError
+	if err := svc.Link(a, "blocks", b); !errors.As(err, &relationErr) {
+		t.Errorf("unknown relation error = %v, want a *RelationError", err)
+	}
+	if err := svc.Link(a, "relates-to", a); err == nil {
+		t.Error("linking a record to itself should fail")
+	}
+	b.Archived = true
+	if err := svc.Link(a, "relates-to", b); !errors.Is(err, ErrArchived) {
+		t.Errorf("archived target error = %v, want ErrArchived", err)
+	}
+}
+
+func TestInferredLinksAcrossCollections(t *testing.T) {
+	storage := NewMemStorage()
+	for _, dir := range []string{"adrs", "payments"} {
+		if err := storage.MkdirAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := cs.Config{Directory: "adrs", Collections: map[string]cs.Config{"payments": {Directory: "payments"}}}
+	svc, err := Open(storage, cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	payments, err := svc.OpenCollection("payments")
+	if err != nil {
+		t.Fatalf("OpenCollection: %v", err)
+	}
+	a, _ := svc.CreateRecord("A", AdrData{ID: "a", Status: ACCEPTED}, "")
+	p, _ := payments.CreateRecord("P", AdrData{ID: "p", Status: ACCEPTED}, "")
+
+	// The link is only stored by the record of the other collection.
+	p.AddLink("amends", payments.Ref(a))
+	if err := payments.UpdateRecord(p); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	got := svc.InferredLinks(a)
+	if len(got["amended-by"]) != 1 || got["amended-by"][0] != "payments:p" {
+		t.Errorf("InferredLinks = %v, want amended-by payments:p", got)
+	}
+
+	// Once held back in reference form, it is no longer inferred.
+	a.AddLink("amended-by", svc.Ref(p))
+	if got := svc.InferredLinks(a); len(got) != 0 {
+		t.Errorf("InferredLinks = %v, want none", got)
+	}
+}
+
+func TestServiceLinkConflict(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	a, _ := svc.CreateRecord("A", AdrData{ID: "a", Status: ACCEPTED}, "")
+	b, _ := svc.CreateRecord("B", AdrData{ID: "b", Status: ACCEPTED}, "")
+	if err := storage.WriteFile("adrs/"+b.Name, []byte("---\nid: b\ntitle: B\nstatus: accepted\n---\n# B\n\nEdited.\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The target changed on disk: the record is not left with a one-sided link.
+	var conflict *ConflictError
+	if err := svc.Link(a, "amends", b); !errors.As(err, &conflict) {
+		t.Fatalf("Link error = %v, want a *ConflictError", err)
+	}
+	reopened, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, s := range []*Service{svc, reopened} {
+		if a, _ := s.GetRecord("a"); len(a.Links) != 0 {
+			t.Errorf("a links = %v, want none", a.Links)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package records
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gwleclerc/adr/utils"
+)
+
+// Move is a record file renamed to take a new number.
+type Move struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// numberedRecord is a record along with the number prefix of its filename.
+type numberedRecord struct {
+	AdrData
+	number int
+	prefix string
+}
+
+// renumberPrefix is put before the names of the files Renumber renames while it
+// renames them.
+const renumberPrefix = ".renumber-"
+
+// PlanRenumber computes the renames that give every record its own number. When
+// several records share a number (e.g. two branches each created their 012), the
+// oldest one by creation date keeps it and the others take the next free numbers,
+// in creation order. With gaps, every record is renumbered from 1 without holes,
+// keeping the current order. With per-category numbering, each category is
+// renumbered on its own. Drafts and archived records are not renumbered, and
+// the numbers of archived records are not reused. IDs are left unchanged.
+func (s Service) PlanRenumber(gaps bool) []Move {
+	pools := map[string][]numberedRecord{}
+	archived := map[string]map[int]bool{}
+	for _, r := range s.records {
+		prefix := utils.GetRecordNumber(path.Base(r.Name))
+		if r.Draft || prefix == "" || !strings.HasPrefix(path.Base(r.Name), prefix) {
+			continue
+		}
+		pool := ""
+		if s.perCategory {
+			pool = r.Category
+		}
+		n, _ := strconv.Atoi(prefix)
+		if r.Archived {
+			// Archived records are read-only: they keep their number.
+			if archived[pool] == nil {
+				archived[pool] = map[int]bool{}
+			}
+			archived[pool][n] = true
+			continue
+		}
+		pools[pool] = append(pools[pool], numberedRecord{r, n, prefix})
+	}
+
+	names := make([]string, 0, len(pools))
+	for pool := range pools {
+		names = append(names, pool)
+	}
+	sort.Strings(names)
+	var moves []Move
+	for _, pool := range names {
+		moves = append(moves, planPool(pools[pool], archived[pool], gaps)...)
+	}
+	return moves
+}
+
+// planPool computes the renames within one numbering sequence.
+func planPool(adrs []numberedRecord, archived map[int]bool, gaps bool) []Move {
+	sort.Slice(adrs, func(i, j int) bool {
+		a, b := adrs[i], adrs[j]
+		if a.number != b.number {
+			return a.number < b.number
+		}
+		if !a.CreationDate.Equal(b.CreationDate) {
+			return a.CreationDate.Before(b.CreationDate)
+		}
+		return a.Name < b.Name
+	})
+
+	targets := make([]int, len(adrs))
+	if gaps {
+		number := 0
+		for i := range adrs {
+			number++
+			for archived[number] {
+				number++
+			}
+			targets[i] = number
+		}
+	} else {
+		highest := 0
+		used := map[int]bool{}
+		for n := range archived {
+			used[n] = true
+			highest = max(highest, n)
+		}
+		for _, a := range adrs {
+			highest = max(highest, a.number)
+		}
+		var duplicates []int
+		for i, a := range adrs {
+			if used[a.number] {
+				duplicates = append(duplicates, i)
+				continue
+			}
+			used[a.number] = true
+			targets[i] = a.number
+		}
+		sort.SliceStable(duplicates, func(i, j int) bool {
+			return adrs[duplicates[i]].CreationDate.Before(adrs[duplicates[j]].CreationDate)
+		})
+		for _, i := range duplicates {
+			highest++
+			targets[i] = highest
+		}
+	}
+
+	var moves []Move
+	for i, a := range adrs {
+		if targets[i] == a.number {
+			continue
+		}
+		base := fmt.Sprintf("%03d", targets[i]) + strings.TrimPrefix(path.Base(a.Name), a.prefix)
+		moves = append(moves, Move{ID: a.ID, From: a.Name, To: path.Join(path.Dir(a.Name), base)})
+	}
+	return moves
+}
+
+// Renumber renames record files as planned by PlanRenumber and rewrites the links
+// to them across the ADR directory, and the references bodies make to them by
+// number ("[[ADR-004]]"). It returns the names of the files it rewrote. When a
+// rename fails, the files already renamed get their names back.
+func (s Service) Renumber(moves []Move) ([]string, error) {
+	if len(moves) == 0 {
+		return nil, nil
+	}
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// References are resolved as they were before the renames.
+	lookup := s.referenceLookup()
+	renames := make(map[string]string, len(moves))
+	numbers := make(map[string]string, len(moves))
+	for _, m := range moves {
+		renames[m.From] = m.To
+		numbers[m.ID] = utils.GetRecordNumber(path.Base(m.To))
+	}
+	for _, m := range moves {
+		if _, moving := renames[m.To]; moving {
+			continue
+		}
+		if _, err := s.storage.Stat(s.file(m.To)); err == nil {
+			return nil, fmt.Errorf("%q already exists", m.To)
+		}
+	}
+
+	// Go through hidden temporary names, so renames can be chained or swapped.
+	temp := func(name string) string {
+		return s.file(path.Join(path.Dir(name), renumberPrefix+path.Base(name)))
+	}
+	var done [][2]string
+	rename := func(from, to string) error {
+		if err := s.storage.Rename(from, to); err != nil {
+			return s.undoRenames(done, err)
+		}
+		done = append(done, [2]string{from, to})
+		return nil
+	}
+	for _, m := range moves {
+		if err := rename(s.file(m.From), temp(m.From)); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range moves {
+		if err := rename(temp(m.From), s.file(m.To)); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range moves {
+		if r, ok := s.records[m.ID]; ok {
+			r.Name = m.To
+			s.records[m.ID] = r
+		}
+	}
+	changed, err := s.rewriteLinks(renames)
+	if err != nil {
+		return changed, err
+	}
+	referencing, err := s.rewriteNumberReferences(lookup, numbers)
+	for _, name := range referencing {
+		if !slices.Contains(changed, name) {
+			changed = append(changed, name)
+		}
+	}
+	return changed, err
+}
+
+// rewriteNumberReferences rewrites the references bodies make by number to
+// renumbered records ("[[ADR-4]]" becomes "[[ADR-7]]"), so they keep designating
+// the same records. lookup resolves references as they were before renumbering,
+// and numbers holds the new numbers of the renumbered records, by ID. It returns
+// the files it changed.
+func (s Service) rewriteNumberReferences(lookup func(ref string) (AdrData, error), numbers map[string]string) ([]string, error) {
+	var changed []string
+	for _, id := range s.ids {
+		r := s.records[id]
+		if r.referencesKnown && len(r.references) == 0 {
+			continue
+		}
+		b, err := s.Content(r)
+		if err != nil {
+			return changed, err
+		}
+		header, err := frontMatterPrefix(bytes.NewReader(b))
+		if err != nil {
+			return changed, err
+		}
+		body := replaceReferences(string(b[len(header):]), func(_ int, ref, _, match string) string {
+			if numberRefRegex.FindStringSubmatch(ref) == nil {
+				return match
+			}
+			target, err := lookup(ref)
+			if err != nil {
+				return match
+			}
+			number, ok := numbers[target.ID]
+			if !ok {
+				return match
+			}
+			return strings.Replace(match, ref, renumberedRef(ref, number), 1)
+		})
+		if body == string(b[len(header):]) {
+			continue
+		}
+		if err := s.writeRewritten(r.Name, string(header)+body); err != nil {
+			return changed, err
+		}
+		changed = append(changed, r.Name)
+	}
+	return changed, nil
+}
+
+// renumberedRef gives a number reference ("ADR-4", "adr_004") a new number,
+// keeping how it is written: "ADR-004" with number "012" becomes "ADR-012",
+// "ADR-4" becomes "ADR-12".
+func renumberedRef(ref, number string) string {
+	n, _ := strconv.Atoi(number)
+	i := strings.IndexFunc(ref, unicode.IsDigit)
+	return ref[:i] + fmt.Sprintf("%0*d", len(ref)-i, n)
+}
+
+// undoRenames renames files back, latest rename first, after err stopped a
+// series of renames. Files that cannot be renamed back are added to the error;
+// the next indexing reports those left under a temporary name.
+func (s Service) undoRenames(done [][2]string, err error) error {
+	for i := len(done) - 1; i >= 0; i-- {
+		from, to := done[i][0], done[i][1]
+		if undoErr := s.storage.Rename(to, from); undoErr != nil {
+			err = errors.Join(err, fmt.Errorf("unable to rename %q back to %q: %w", to, from, undoErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package records
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestPlanRenumber(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	svc := Service{records: map[string]AdrData{
+		"a": {ID: "a", Name: "001_a.md", CreationDate: day(1)},
+		"b": {ID: "b", Name: "003_b.md", CreationDate: day(2)},
+		"c": {ID: "c", Name: "003_c.md", CreationDate: day(4)},
+		"d": {ID: "d", Name: "003_d.md", CreationDate: day(3)},
+		"e": {ID: "e", Name: "005_e.md", CreationDate: day(5)},
+		"f": {ID: "f", Name: "README.md"},
+	}}
+
+	// The oldest 003 keeps its number, the others follow the highest one.
+	got := svc.PlanRenumber(false)
+	want := []Move{
+		{ID: "d", From: "003_d.md", To: "006_d.md"},
+		{ID: "c", From: "003_c.md", To: "007_c.md"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanRenumber(false) = %v, want %v", got, want)
+	}
+
+	got = svc.PlanRenumber(true)
+	want = []Move{
+		{ID: "b", From: "003_b.md", To: "002_b.md"},
+		{ID: "c", From: "003_c.md", To: "004_c.md"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanRenumber(true) = %v, want %v", got, want)
+	}
+}
+
+func TestServiceRenumber(t *testing.T) {
+	newTestProject(t)
+
+	files := map[string]string{
+		"001_a.md": "---\nid: a\ntitle: A\nstatus: accepted\ncreation_date: 2026-01-01T00:00:00Z\n---\n# A\nsee [b](002_b.md)\n",
+		"002_b.md": "---\nid: b\ntitle: B\nstatus: accepted\ncreation_date: 2026-01-02T00:00:00Z\n---\n# B\nsee [c](002_c.md)\n",
+		"002_c.md": "---\nid: c\ntitle: C\nstatus: accepted\ncreation_date: 2026-01-03T00:00:00Z\n---\n# C\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join("adrs", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	moves := svc.PlanRenumber(false)
+	if len(moves) != 1 || moves[0].To != "003_c.md" {
+		t.Fatalf("moves = %v, want 002_c.md -> 003_c.md", moves)
+	}
+	changed, err := svc.Renumber(moves)
+	if err != nil {
+		t.Fatalf("Renumber: %v", err)
+	}
+	if !reflect.DeepEqual(changed, []string{"002_b.md"}) {
+		t.Errorf("changed = %v, want [002_b.md]", changed)
+	}
+	if r, _ := svc.GetRecord("c"); r.Name != "003_c.md" {
+		t.Errorf("indexed name = %q, want 003_c.md", r.Name)
+	}
+
+	svc, _ = NewService()
+	b, _ := svc.GetRecord("b")
+	if !strings.Contains(b.Body, "[c](003_c.md)") {
+		t.Errorf("link not rewritten:\n%s", b.Body)
+	}
+	if moves := svc.PlanRenumber(false); len(moves) != 0 {
+		t.Errorf("records still collide: %v", moves)
+	}
+}
+
+func TestServiceRenumberReferences(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"adrs/001_a.md": "---\nid: a\ntitle: A\nstatus: accepted\n---\nsee [[ADR-3]], [[ADR-003|b]] and `[[ADR-3]]`\n",
+		"adrs/003_b.md": "---\nid: b\ntitle: B\nstatus: accepted\n---\nsee [[ADR-1]]\n",
+	} {
+		if err := storage.WriteFile(name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"}, WithMetadataOnly())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	changed, err := svc.Renumber(svc.PlanRenumber(true))
+	if err != nil {
+		t.Fatalf("Renumber: %v", err)
+	}
+	if !reflect.DeepEqual(changed, []string{"001_a.md"}) {
+		t.Errorf("changed = %v, want [001_a.md]", changed)
+	}
+	b, _ := storage.ReadFile("adrs/001_a.md")
+	if want := "see [[ADR-2]], [[ADR-002|b]] and `[[ADR-3]]`\n"; !strings.HasSuffix(string(b), want) {
+		t.Errorf("references not rewritten:\n%s", b)
+	}
+	if r, err := svc.LookupReference("ADR-2"); err != nil || r.ID != "b" {
+		t.Errorf("LookupReference(ADR-2) = %v, %v, want b", r.ID, err)
+	}
+}
+
+// failingRenames is a MemStorage failing the renames to the names fail returns
+// true for.
+type failingRenames struct {
+	*MemStorage
+	fail func(newName string) bool
+}
+
+func (f *failingRenames) Rename(oldName, newName string) error {
+	if f.fail(newName) {
+		return errors.New("rename failed")
+	}
+	return f.MemStorage.Rename(oldName, newName)
+}
+
+func TestServiceRenumberRollback(t *testing.T) {
+	storage := &failingRenames{MemStorage: NewMemStorage()}
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"adrs/001_a.md": "---\nid: a\ntitle: A\nstatus: accepted\n---\n# A\n",
+		"adrs/003_b.md": "---\nid: b\ntitle: B\nstatus: accepted\n---\n# B\n",
+	} {
+		if err := storage.WriteFile(name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	open := func() *Service {
+		t.Helper()
+		svc, err := Open(storage, cs.Config{Directory: "adrs"})
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		return svc
+	}
+
+	// The file renamed to its temporary name gets its name back.
+	storage.fail = func(newName string) bool { return newName == "adrs/002_b.md" }
+	svc := open()
+	if _, err := svc.Renumber(svc.PlanRenumber(true)); err == nil {
+		t.Fatal("Renumber should fail")
+	}
+	svc = open()
+	if r, ok := svc.GetRecord("b"); !ok || r.Name != "003_b.md" || len(svc.Diagnostics()) != 0 {
+		t.Errorf("record b = %q, diagnostics %v, want it back as 003_b.md", r.Name, svc.Diagnostics())
+	}
+
+	// A file that cannot be renamed back is reported.
+	storage.fail = func(newName string) bool { return newName != "adrs/.renumber-003_b.md" }
+	if _, err := svc.Renumber(svc.PlanRenumber(true)); err == nil {
+		t.Fatal("Renumber should fail")
+	}
+	diagnostics := open().Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Kind != DiagnosticRenumberLeftover || diagnostics[0].File != ".renumber-003_b.md" {
+		t.Errorf("diagnostics = %+v, want the leftover .renumber-003_b.md", diagnostics)
+	}
+}
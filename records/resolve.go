@@ -0,0 +1,127 @@
+package records
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	simpleSlug "github.com/gosimple/slug"
+	"github.com/gwleclerc/adr/utils"
+)
+
+// numberRefRegex matches record numbers as people write them: "7", "007",
+// "ADR-7", "adr_007" or "ADR 7".
+var numberRefRegex = regexp.MustCompile(`(?i)^(?:adr[-_ ]?)?0*(\d+)$`)
+
+// Resolve finds the record a reference points to. A reference is tried, in
+// order, as an ID, a filename (with or without directory and extension), a
+// number ("7", "007", "ADR-7"), an ID prefix, a title and finally a fuzzy title
+// match.
+// A reference prefixed with a collection name ("payments:007") is resolved in
+// that collection; the record then has its Collection set accordingly.
+// It returns an error matching ErrNotFound when nothing matches, and an
+// *AmbiguousError listing the candidates when several records do.
+func (s Service) Resolve(ref string) (AdrData, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return AdrData{}, &NotFoundError{Ref: ref}
+	}
+	if collection, rest, ok := s.splitCollectionRef(ref); ok {
+		other, err := s.OpenCollection(collection)
+		if err != nil {
+			return AdrData{}, err
+		}
+		record, err := other.Resolve(rest)
+		if errors.Is(err, ErrNotFound) {
+			return AdrData{}, &NotFoundError{Ref: ref}
+		}
+		return record, err
+	}
+	if r, ok := s.records[ref]; ok {
+		return r, nil
+	}
+
+	all := s.GetRecords()
+	stages := []func(AdrData) bool{
+		func(r AdrData) bool { return matchesFilename(r, ref) },
+		numberMatcher(ref),
+		func(r AdrData) bool { return strings.HasPrefix(r.ID, ref) },
+		func(r AdrData) bool { return simpleSlug.Make(r.Title) == simpleSlug.Make(ref) },
+		titleMatcher(ref),
+	}
+	for _, match := range stages {
+		if match == nil {
+			continue
+		}
+		var candidates []AdrData
+		for _, r := range all {
+			if match(r) {
+				candidates = append(candidates, r)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], nil
+		default:
+			return AdrData{}, &AmbiguousError{Ref: ref, Candidates: candidates}
+		}
+	}
+	return AdrData{}, &NotFoundError{Ref: ref}
+}
+
+func matchesFilename(r AdrData, ref string) bool {
+	base, name := filepath.Base(filepath.ToSlash(ref)), path.Base(r.Name)
+	return base == name || base+".md" == name
+}
+
+// numberMatcher matches the records numbered as ref, or returns nil when ref
+// does not look like a number. Drafts are not numbered, whatever their name.
+func numberMatcher(ref string) func(AdrData) bool {
+	match := numberRefRegex.FindStringSubmatch(ref)
+	if match == nil {
+		return nil
+	}
+	want, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil
+	}
+	return func(r AdrData) bool {
+		number := utils.GetRecordNumber(r.Name)
+		if number == "" || r.Draft {
+			return false
+		}
+		n, err := strconv.Atoi(number)
+		return err == nil && n == want
+	}
+}
+
+// titleMatcher matches titles with a word starting with each word of ref,
+// ignoring case, accents and punctuation.
+func titleMatcher(ref string) func(AdrData) bool {
+	want := simpleSlug.Make(ref)
+	if want == "" {
+		return nil
+	}
+	words := strings.Split(want, "-")
+	return func(r AdrData) bool {
+		titleWords := strings.Split(simpleSlug.Make(r.Title), "-")
+		for _, w := range words {
+			found := false
+			for _, tw := range titleWords {
+				if strings.HasPrefix(tw, w) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,63 @@
+package records
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	adrs := []AdrData{
+		{ID: "Xy7abQ", Name: "001_use_postgres.md", Title: "Use Postgres"},
+		{ID: "Xy9cdR", Name: "002_use_postgres_replicas.md", Title: "Use Postgres replicas"},
+		{ID: "k3LmnO", Name: "007_adopt_event_sourcing.md", Title: "Adopt Event Sourcing"},
+		{ID: "p0QrsT", Name: "012_api_versioning.md", Title: "API versioning"},
+		{ID: "p0UvwX", Name: "012_api_pagination.md", Title: "API pagination"},
+		{ID: "d2RoaD", Name: "drafts/2024_roadmap.md", Title: "Roadmap", Draft: true},
+	}
+	svc := Service{records: map[string]AdrData{}}
+	for _, a := range adrs {
+		svc.records[a.ID] = a
+		svc.ids = append(svc.ids, a.ID)
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"k3LmnO", "k3LmnO"},                             // ID
+		{"7", "k3LmnO"},                                  // number
+		{"007", "k3LmnO"},                                // padded number
+		{"ADR-7", "k3LmnO"},                              // ADR-style number
+		{"adr_007", "k3LmnO"},                            // ADR-style number
+		{"007_adopt_event_sourcing.md", "k3LmnO"},        // filename
+		{"docs/adrs/007_adopt_event_sourcing", "k3LmnO"}, // path without extension
+		{"k3L", "k3LmnO"},                                // ID prefix
+		{"use postgres", "Xy7abQ"},                       // exact title wins over partial matches
+		{"event sourcing", "k3LmnO"},                     // fuzzy title
+		{"Adopt évent", "k3LmnO"},                        // case and accents ignored
+		{"repl", "Xy9cdR"},                               // word prefix
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := svc.Resolve(tt.ref)
+			if err != nil {
+				t.Fatalf("Resolve(%q): %v", tt.ref, err)
+			}
+			if got.ID != tt.want {
+				t.Errorf("Resolve(%q) = %s, want %s", tt.ref, got.ID, tt.want)
+			}
+		})
+	}
+
+	var ambiguous *AmbiguousError
+	for _, ref := range []string{"12", "Xy", "api"} {
+		if _, err := svc.Resolve(ref); !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+			t.Errorf("Resolve(%q) = %v, want an ambiguity between 2 records", ref, err)
+		}
+	}
+	for _, ref := range []string{"", "42", "kubernetes", "2024"} { // drafts are not numbered
+		if _, err := svc.Resolve(ref); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Resolve(%q) = %v, want ErrNotFound", ref, err)
+		}
+	}
+}
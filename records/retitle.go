@@ -0,0 +1,84 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gwleclerc/adr/utils"
+)
+
+// RetitleRecord changes a record's title: the front-matter title, the heading of
+// its body and its filename (which keeps its number). Links to the old filename
+// in the other files of the ADR directory are rewritten; their names are
+// returned along with the updated record.
+func (s Service) RetitleRecord(record AdrData, title string) (AdrData, []string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return AdrData{}, nil, errors.New("the title cannot be empty")
+	}
+	if record.Archived {
+		return AdrData{}, nil, &ArchivedError{ID: record.ID}
+	}
+
+	unlock, err := s.storage.Lock(s.adrsPath)
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+	defer unlock()
+
+	if err := s.checkUnchanged(&record); err != nil {
+		return AdrData{}, nil, err
+	}
+
+	oldName := record.Name
+	newName := oldName
+	if record.Draft {
+		newName = path.Join(path.Dir(oldName), filenameSlug(title)+".md")
+	} else if number := utils.GetRecordNumber(oldName); number != "" {
+		newName = path.Join(path.Dir(oldName), fmt.Sprintf("%s_%s.md", number, filenameSlug(title)))
+	}
+	if newName != oldName {
+		if _, err := s.storage.Stat(s.file(newName)); err == nil {
+			return AdrData{}, nil, fmt.Errorf("%q already exists", newName)
+		}
+	}
+
+	record.Title = title
+	record.Body = replaceTitleHeading(record.Body, title)
+	record.LastUpdateDate = time.Now()
+	record.Name = newName
+
+	header, err := MarshalYAML(record)
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+	sum, err := s.writeRecord(newName, string(header), record.Body)
+	if err != nil {
+		return AdrData{}, nil, err
+	}
+	record.checksum = sum
+	s.records[record.ID] = record
+	if newName == oldName {
+		return record, nil, nil
+	}
+	if err := s.storage.Remove(s.file(oldName)); err != nil {
+		return record, nil, err
+	}
+	changed, err := s.rewriteLinks(map[string]string{oldName: newName})
+	return record, changed, err
+}
+
+// replaceTitleHeading replaces the first level-1 heading of a body.
+func replaceTitleHeading(body, title string) string {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "# ") {
+			lines[i] = "# " + title
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,16 @@
 package records
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
 )
 
 // newTestService sets up an isolated ADR project in a temp dir and returns a service.
@@ -103,3 +110,344 @@ func TestServiceIgnoresNonRecords(t *testing.T) {
 		t.Errorf("expected 1 record (non-records ignored), got %d: %+v", len(got), got)
 	}
 }
+
+func TestServiceConcurrentCreate(t *testing.T) {
+	newTestProject(t)
+
+	// Every writer indexes the (empty) directory up front, as parallel `adr new`
+	// runs would, so they all start from the same stale view of the numbers.
+	const writers = 8
+	services := make([]*Service, writers)
+	for i := range services {
+		svc, err := NewService()
+		if err != nil {
+			t.Fatalf("NewService: %v", err)
+		}
+		services[i] = svc
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i, svc := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := AdrData{ID: fmt.Sprintf("id%d", i), Status: ACCEPTED, Tags: make(Set[string])}
+			if _, err := svc.CreateRecord("Same Title", rec, "## Context\nx\n"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("CreateRecord: %v", err)
+	}
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService (reindex): %v", err)
+	}
+	got := svc.GetRecords()
+	if len(got) != writers {
+		t.Fatalf("expected %d records, got %d: %+v", writers, len(got), got)
+	}
+	for i, r := range got {
+		if want := fmt.Sprintf("%03d_same_title.md", i+1); r.Name != want {
+			t.Errorf("record %d filename = %q, want %q", i, r.Name, want)
+		}
+	}
+
+	// Neither the lock nor temporary files are left behind.
+	entries, err := os.ReadDir("adrs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("unexpected leftover file %q", e.Name())
+		}
+	}
+}
+
+func TestServiceUpdateConflict(t *testing.T) {
+	newTestProject(t)
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	created, err := svc.CreateRecord("A decision", AdrData{ID: "a", Status: ACCEPTED, Tags: make(Set[string])}, "## Context\nx\n")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	svc, _ = NewService()
+	r, _ := svc.GetRecord("a")
+
+	// Someone edits the file (e.g. in $EDITOR) after it was indexed.
+	path := filepath.Join("adrs", created.Name)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(b, "\nEdited by hand.\n"...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Status = DEPRECATED
+	err = svc.UpdateRecord(r)
+	var conflict *ConflictError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &conflict) || conflict.File != created.Name {
+		t.Fatalf("UpdateRecord on a modified file = %v, want a conflict on %q", err, created.Name)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "Edited by hand.") {
+		t.Error("the manual edit should not have been overwritten")
+	}
+
+	// Forcing overwrites, and the refreshed index allows a follow-up update.
+	svc.SetForce(true)
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Fatalf("forced UpdateRecord: %v", err)
+	}
+	svc.SetForce(false)
+	r, _ = svc.GetRecord("a")
+	r.Tags.Append("x")
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Errorf("UpdateRecord after our own write: %v", err)
+	}
+}
+
+func TestServiceUpdateTransition(t *testing.T) {
+	newTestProject(t)
+	config := "directory: adrs\nstatuses:\n  - name: rejected\ntransitions:\n  proposed: [accepted, rejected]\n  accepted: [deprecated]\n"
+	if err := os.WriteFile(".adrrc.yml", []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if _, err := svc.CreateRecord("A decision", AdrData{ID: "a", Status: PROPOSED, Tags: make(Set[string])}, "## Context\nx\n"); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	svc, _ = NewService()
+	r, _ := svc.GetRecord("a")
+	r.Status = "rejected"
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Fatalf("allowed transition: %v", err)
+	}
+	r, _ = svc.GetRecord("a")
+	r.Status = ACCEPTED
+	if err := svc.UpdateRecord(r); !errors.Is(err, ErrTransition) {
+		t.Fatalf("UpdateRecord from a final status = %v, want ErrTransition", err)
+	}
+	svc.SetForce(true)
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Errorf("forced transition: %v", err)
+	}
+}
+
+func TestServiceStatusHistory(t *testing.T) {
+	newTestProject(t)
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if _, err := svc.CreateRecord("A decision", AdrData{ID: "a", Author: "alice", Status: PROPOSED, Tags: make(Set[string])}, "## Context\nx\n"); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	svc, _ = NewService()
+	svc.SetActor("bob")
+	svc.SetReason("approved at review")
+	r, _ := svc.GetRecord("a")
+	r.Status = ACCEPTED
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	// A change that keeps the status does not add an entry.
+	r, _ = svc.GetRecord("a")
+	r.Tags.Append("x")
+	if err := svc.UpdateRecord(r); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+
+	svc, _ = NewService()
+	r, _ = svc.GetRecord("a")
+	if len(r.History) != 2 {
+		t.Fatalf("history = %+v, want 2 entries", r.History)
+	}
+	first, second := r.History[0], r.History[1]
+	if first.Status != PROPOSED || first.Actor != "alice" || first.Date.IsZero() {
+		t.Errorf("creation entry = %+v", first)
+	}
+	if second.Status != ACCEPTED || second.Actor != "bob" || second.Reason != "approved at review" || second.Date.Before(first.Date) {
+		t.Errorf("update entry = %+v", second)
+	}
+
+	// The creation is credited to the actor when there is one.
+	svc.SetActor("carol")
+	created, err := svc.CreateRecord("Another decision", AdrData{ID: "b", Author: "alice", Status: PROPOSED, Tags: make(Set[string])}, "## Context\nx\n")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if len(created.History) != 1 || created.History[0].Actor != "carol" {
+		t.Errorf("creation history = %+v, want an entry by carol", created.History)
+	}
+}
+
+func TestServiceRetitle(t *testing.T) {
+	newTestProject(t)
+
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	first, err := svc.CreateRecord("Use Postgres", AdrData{ID: "a", Status: ACCEPTED, Tags: make(Set[string])}, "## Context\nx\n")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if _, err := svc.CreateRecord("Add replicas", AdrData{ID: "b", Status: ACCEPTED, Tags: make(Set[string])}, "## Context\nbuilds on [it](001_use_postgres.md)\n"); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+
+	svc, _ = NewService()
+	r, _ := svc.GetRecord(first.ID)
+	updated, changed, err := svc.RetitleRecord(r, "Use PostgreSQL 16")
+	if err != nil {
+		t.Fatalf("RetitleRecord: %v", err)
+	}
+	if updated.Name != "001_use_postgresql_16.md" || updated.Title != "Use PostgreSQL 16" {
+		t.Errorf("retitled record = %q / %q", updated.Name, updated.Title)
+	}
+	if len(changed) != 1 || changed[0] != "002_add_replicas.md" {
+		t.Errorf("changed = %v, want the record linking to the old name", changed)
+	}
+	if _, err := os.Stat(filepath.Join("adrs", "001_use_postgres.md")); !os.IsNotExist(err) {
+		t.Error("the old file should be gone")
+	}
+
+	svc, _ = NewService()
+	r, _ = svc.GetRecord(first.ID)
+	if !strings.Contains(r.Body, "# Use PostgreSQL 16\n") {
+		t.Errorf("heading not updated:\n%s", r.Body)
+	}
+	other, _ := svc.GetRecord("b")
+	if !strings.Contains(other.Body, "[it](001_use_postgresql_16.md)") {
+		t.Errorf("link not rewritten:\n%s", other.Body)
+	}
+}
+
+func TestServiceMetadataOnly(t *testing.T) {
+	newTestProject(t)
+	svc, err := NewService()
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	created, err := svc.CreateRecord("Lazy", AdrData{ID: "lazy", Status: PROPOSED}, "## Context\nbecause\n")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	path := filepath.Join("adrs", created.Name)
+
+	svc, err = NewService(WithMetadataOnly())
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	record, _ := svc.GetRecord("lazy")
+	if record.Body != "" || record.Title != "Lazy" {
+		t.Fatalf("metadata-only record = %q with body %q", record.Title, record.Body)
+	}
+	if body, err := svc.Body(record); err != nil || !strings.Contains(body, "because") {
+		t.Errorf("Body() = %q, %v", body, err)
+	}
+
+	// An edit to the body made since indexing is kept when the record is rewritten.
+	b, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(b), "because", "since", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	record.Status = ACCEPTED
+	if err := svc.UpdateRecord(record); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	b, _ = os.ReadFile(path)
+	if !strings.Contains(string(b), "status: accepted") || !strings.Contains(string(b), "since") {
+		t.Errorf("updated file:\n%s", b)
+	}
+
+	// An edit to the front-matter is a conflict.
+	svc, err = NewService(WithMetadataOnly())
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	record, _ = svc.GetRecord("lazy")
+	if err := os.WriteFile(path, []byte(strings.Replace(string(b), "title: Lazy", "title: Eager", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	record.Status = DEPRECATED
+	if err := svc.UpdateRecord(record); !errors.Is(err, ErrConflict) {
+		t.Errorf("UpdateRecord() = %v, want a conflict", err)
+	}
+}
+
+// countingReads is a MemStorage counting the bytes read from files.
+type countingReads struct {
+	*MemStorage
+	read int
+}
+
+func (c *countingReads) Open(name string) (fs.File, error) {
+	f, err := c.MemStorage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return countingFile{File: f, read: &c.read}, nil
+}
+
+func (c *countingReads) ReadFile(name string) ([]byte, error) {
+	b, err := c.MemStorage.ReadFile(name)
+	c.read += len(b)
+	return b, err
+}
+
+type countingFile struct {
+	fs.File
+	read *int
+}
+
+func (f countingFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	*f.read += n
+	return n, err
+}
+
+func TestServiceMetadataOnlyReads(t *testing.T) {
+	storage := &countingReads{MemStorage: NewMemStorage()}
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	body := strings.Repeat("A long body that mentions [[ADR-2]].\n", 1000)
+	for _, name := range []string{"001_a.md", "002_b.md"} {
+		content := "---\nid: " + name[4:5] + "\ntitle: T\nstatus: accepted\n---\n" + body
+		if err := storage.WriteFile("adrs/"+name, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"}, WithMetadataOnly(), WithoutCache())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if storage.read >= len(body) {
+		t.Errorf("indexing read %d bytes, want only the front-matters", storage.read)
+	}
+	// The bodies are read when backlinks need their references.
+	b, _ := svc.GetRecord("b")
+	if backlinks := svc.Backlinks(b); len(backlinks) != 1 || backlinks[0].ID != "a" {
+		t.Errorf("Backlinks = %+v, want a", backlinks)
+	}
+}
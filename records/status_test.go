@@ -3,11 +3,14 @@ package records
 import (
 	"strings"
 	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
 )
 
 func TestParseStatus(t *testing.T) {
+	w := DefaultWorkflow()
 	for _, s := range AdrStatuses {
-		got, err := ParseStatus(string(s))
+		got, err := w.ParseStatus(string(s))
 		if err != nil {
 			t.Errorf("ParseStatus(%q) returned error: %v", s, err)
 		}
@@ -15,13 +18,13 @@ func TestParseStatus(t *testing.T) {
 			t.Errorf("ParseStatus(%q) = %q, want %q", s, got, s)
 		}
 	}
-	if _, err := ParseStatus("bogus"); err == nil {
+	if _, err := w.ParseStatus("bogus"); err == nil {
 		t.Error("ParseStatus(\"bogus\") should return an error")
 	}
 }
 
 func TestAllowedStatuses(t *testing.T) {
-	got := AllowedStatuses()
+	got := DefaultWorkflow().AllowedStatuses()
 	for _, s := range AdrStatuses {
 		if !strings.Contains(got, string(s)) {
 			t.Errorf("AllowedStatuses() = %q, missing %q", got, s)
@@ -31,3 +34,97 @@ func TestAllowedStatuses(t *testing.T) {
 		t.Errorf("AllowedStatuses() = %q, expected an \" or \" separator", got)
 	}
 }
+
+func TestNewWorkflow(t *testing.T) {
+	w, err := NewWorkflow(
+		[]cs.StatusSpec{
+			{Name: "rejected", Description: "turned down", Color: "red"},
+			{Name: "accepted", Color: "blue"},
+		},
+		map[string][]string{
+			"proposed": {"accepted", "rejected"},
+			"accepted": {"deprecated", "superseded"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewWorkflow: %v", err)
+	}
+	if !w.Declared("rejected") || !w.Declared(DEPRECATED) {
+		t.Errorf("declared statuses should extend the built-ins, got %v", w.Statuses())
+	}
+	if w.Description("rejected") != "turned down" {
+		t.Errorf("Description(rejected) = %q", w.Description("rejected"))
+	}
+	tests := []struct {
+		from, to AdrStatus
+		want     bool
+	}{
+		{PROPOSED, ACCEPTED, true},
+		{PROPOSED, "rejected", true},
+		{ACCEPTED, PROPOSED, false},
+		{"rejected", ACCEPTED, false}, // no entry: final
+		{"rejected", "rejected", true},
+	}
+	for _, tt := range tests {
+		if got := w.CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+	if !DefaultWorkflow().CanTransition(ACCEPTED, PROPOSED) {
+		t.Error("the default workflow should not restrict transitions")
+	}
+}
+
+func TestNewWorkflowInvalid(t *testing.T) {
+	if _, err := NewWorkflow([]cs.StatusSpec{{Name: "x", Color: "pink"}}, nil); err == nil {
+		t.Error("an unknown color should be rejected")
+	}
+	if _, err := NewWorkflow(nil, map[string][]string{"accepted": {"ghost"}}); err == nil {
+		t.Error("a transition to an undeclared status should be rejected")
+	}
+}
+
+func TestWorkflowParseStatus(t *testing.T) {
+	w, err := NewWorkflow([]cs.StatusSpec{{Name: "in-review"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.ParseStatus("in-review"); err != nil {
+		t.Errorf("ParseStatus(in-review) with a declared status: %v", err)
+	}
+	if !strings.Contains(w.AllowedStatuses(), `"in-review"`) {
+		t.Errorf("AllowedStatuses() = %q, missing the declared status", w.AllowedStatuses())
+	}
+	if _, err := DefaultWorkflow().ParseStatus("in-review"); err == nil {
+		t.Error("another workflow should not know the declared status")
+	}
+}
+
+func TestServiceParseStatus(t *testing.T) {
+	storage := NewMemStorage()
+	for _, dir := range []string{"adrs", "payments"} {
+		if err := storage.MkdirAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := cs.Config{
+		Directory: "adrs",
+		Collections: map[string]cs.Config{
+			"payments": {Directory: "payments", Statuses: []cs.StatusSpec{{Name: "approved"}}},
+		},
+	}
+	root, err := Open(storage, config)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	payments, err := Open(storage, config, WithCollection("payments"))
+	if err != nil {
+		t.Fatalf("Open(payments): %v", err)
+	}
+	if _, err := payments.ParseStatus("approved"); err != nil {
+		t.Errorf("ParseStatus(approved) in the collection declaring it: %v", err)
+	}
+	if _, err := root.ParseStatus("approved"); err == nil {
+		t.Error("ParseStatus(approved) should fail where it is not declared")
+	}
+}
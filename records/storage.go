@@ -0,0 +1,177 @@
+package records
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Storage holds the files of a project: its configuration file, its records and
+// its index cache. Names are slash-separated paths relative to the project
+// directory (the one that holds the configuration file); a Storage may accept
+// names that fs.ValidPath rejects, such as "../shared/adrs".
+type Storage interface {
+	fs.StatFS
+	fs.ReadDirFS
+	fs.ReadFileFS
+	// WriteFile replaces the content of a file, or creates it, atomically: a
+	// failed write never leaves a half-written file behind.
+	WriteFile(name string, data []byte) error
+	// CreateExclusive creates an empty file, failing with an error matching
+	// fs.ErrExist when the file already exists.
+	CreateExclusive(name string) error
+	Rename(oldName, newName string) error
+	Remove(name string) error
+	MkdirAll(name string) error
+	// Lock takes an exclusive lock on a directory, so that concurrent writers
+	// do not allocate the same number or overwrite each other's changes. The
+	// returned function releases it.
+	Lock(dir string) (func(), error)
+}
+
+// OSStorage is the Storage of a project directory on the local file system.
+type OSStorage struct {
+	dir string
+}
+
+// NewOSStorage returns the Storage of a project directory.
+func NewOSStorage(dir string) *OSStorage {
+	return &OSStorage{dir: dir}
+}
+
+// Dir returns the project directory.
+func (o *OSStorage) Dir() string {
+	return o.dir
+}
+
+func (o *OSStorage) path(name string) string {
+	return filepath.Join(o.dir, filepath.FromSlash(name))
+}
+
+func (o *OSStorage) Open(name string) (fs.File, error) {
+	return os.Open(o.path(name))
+}
+
+func (o *OSStorage) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(o.path(name))
+}
+
+func (o *OSStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(o.path(name))
+}
+
+func (o *OSStorage) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(o.path(name))
+}
+
+func (o *OSStorage) WriteFile(name string, data []byte) error {
+	return writeFileAtomic(o.path(name), data, 0o644)
+}
+
+func (o *OSStorage) CreateExclusive(name string) error {
+	f, err := os.OpenFile(o.path(name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (o *OSStorage) Rename(oldName, newName string) error {
+	return os.Rename(o.path(oldName), o.path(newName))
+}
+
+func (o *OSStorage) Remove(name string) error {
+	return os.Remove(o.path(name))
+}
+
+func (o *OSStorage) MkdirAll(name string) error {
+	return os.MkdirAll(o.path(name), 0o755)
+}
+
+func (o *OSStorage) Lock(dir string) (func(), error) {
+	return lockDir(o.path(dir))
+}
+
+// FSStorage is a read-only Storage serving the files of an fs.FS, such as an
+// embed.FS or an archive. Its writes fail with an error matching ErrReadOnly.
+type FSStorage struct {
+	fsys fs.FS
+}
+
+// NewFSStorage returns a read-only Storage of the files of fsys, rooted at the
+// project directory.
+func NewFSStorage(fsys fs.FS) *FSStorage {
+	return &FSStorage{fsys: fsys}
+}
+
+func (f *FSStorage) Open(name string) (fs.File, error) {
+	return f.fsys.Open(name)
+}
+
+func (f *FSStorage) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, name)
+}
+
+func (f *FSStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.fsys, name)
+}
+
+func (f *FSStorage) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fsys, name)
+}
+
+func (f *FSStorage) WriteFile(name string, _ []byte) error {
+	return readOnly("write", name)
+}
+
+func (f *FSStorage) CreateExclusive(name string) error {
+	return readOnly("create", name)
+}
+
+func (f *FSStorage) Rename(oldName, _ string) error {
+	return readOnly("rename", oldName)
+}
+
+func (f *FSStorage) Remove(name string) error {
+	return readOnly("remove", name)
+}
+
+func (f *FSStorage) MkdirAll(name string) error {
+	return readOnly("mkdir", name)
+}
+
+func (f *FSStorage) Lock(dir string) (func(), error) {
+	return nil, readOnly("lock", dir)
+}
+
+func readOnly(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: ErrReadOnly}
+}
+
+// osDir returns the project directory of a storage on the local file system,
+// or "" for other storages.
+func osDir(storage Storage) string {
+	if o, ok := storage.(*OSStorage); ok {
+		return o.dir
+	}
+	return ""
+}
+
+// relPath returns the slash-separated path of target relative to base, both
+// slash-separated paths of the same storage.
+func relPath(base, target string) (string, error) {
+	rel, err := filepath.Rel(filepath.FromSlash(base), filepath.FromSlash(target))
+	return filepath.ToSlash(rel), err
+}
+
+// exists reports whether a file of the storage exists.
+func exists(storage Storage, name string) bool {
+	_, err := storage.Stat(name)
+	return err == nil
+}
+
+// cleanName turns a configured path into a storage name.
+func cleanName(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
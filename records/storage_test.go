@@ -0,0 +1,133 @@
+package records
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestMemStorageLifecycle(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("docs/adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "docs/adrs", TOC: "docs/adrs/README.md"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	first, err := svc.CreateRecord("First", AdrData{ID: "first", Status: ACCEPTED}, "")
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	draft, err := svc.CreateRecord("Second", AdrData{ID: "second", Status: PROPOSED, Draft: true}, "see [first](../001_first.md)")
+	if err != nil {
+		t.Fatalf("CreateRecord draft: %v", err)
+	}
+	if _, _, err := svc.PromoteRecord(draft, ACCEPTED); err != nil {
+		t.Fatalf("PromoteRecord: %v", err)
+	}
+	if _, _, err := svc.ArchiveRecord(first); err != nil {
+		t.Fatalf("ArchiveRecord: %v", err)
+	}
+	if err := svc.WriteTOC("# Index\n"); err != nil {
+		t.Fatalf("WriteTOC: %v", err)
+	}
+
+	for _, name := range []string{"docs/adrs/002_second.md", "docs/adrs/archive/001_first.md", "docs/adrs/README.md"} {
+		if _, err := storage.Stat(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"docs/adrs/001_first.md", "docs/adrs/drafts/second.md"} {
+		if _, err := storage.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s should be gone, got %v", name, err)
+		}
+	}
+	b, _ := storage.ReadFile("docs/adrs/002_second.md")
+	if want := "see [first](archive/001_first.md)"; !strings.Contains(string(b), want) {
+		t.Errorf("promoted record = %q, want its link rewritten to %q", b, want)
+	}
+
+	// A fresh service indexes what was written.
+	again, err := Open(storage, cs.Config{Directory: "docs/adrs"})
+	if err != nil {
+		t.Fatalf("Open again: %v", err)
+	}
+	got := again.GetRecords()
+	if len(got) != 2 || got[0].ID != "second" || !got[1].Archived {
+		t.Errorf("records = %+v, want second then the archived first", got)
+	}
+}
+
+func TestMemStorageFS(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs/archive"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"adrs/001_a.md", "adrs/archive/002_b.md"} {
+		if err := storage.WriteFile(name, []byte("# "+name+"\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fstest.TestFS(storage, "adrs/001_a.md", "adrs/archive/002_b.md"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFSStorageIsReadOnly(t *testing.T) {
+	fsys := fstest.MapFS{
+		".adrrc.yml":        {Data: []byte("directory: adrs\n")},
+		"adrs/001_first.md": {Data: []byte("---\nid: first\ntitle: First\nstatus: accepted\n---\n\n# First\n")},
+	}
+	storage := NewFSStorage(fsys)
+	config, err := ReadConfig(storage)
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	svc, err := Open(storage, config)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	record, ok := svc.GetRecord("first")
+	if !ok || record.Title != "First" {
+		t.Fatalf("records = %+v, want the first record", svc.GetRecords())
+	}
+	record.Status = DEPRECATED
+	if err := svc.UpdateRecord(record); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("UpdateRecord error = %v, want ErrReadOnly", err)
+	}
+	if _, err := svc.CreateRecord("Second", AdrData{ID: "second"}, ""); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("CreateRecord error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestCopyToMemoryLeavesFilesAlone(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "adrs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	storage, err := CopyToMemory(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("CopyToMemory: %v", err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := svc.CreateRecord("Dry run", AdrData{ID: "dry"}, ""); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if _, err := storage.Stat("adrs/001_dry_run.md"); err != nil {
+		t.Errorf("in memory: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "adrs")); len(entries) != 0 {
+		t.Errorf("disk = %v, want untouched", entries)
+	}
+}
@@ -0,0 +1,60 @@
+package records
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// SyncSupersession completes on the other side the supersession a record
+// holds: each of its superseders lists it under supersedes, and each record it
+// supersedes lists it as a superseder, with the superseded status. References
+// to records that do not exist and archived records are left alone. It returns
+// the records it updated; failing to update one does not stop the others, the
+// errors are joined.
+func (s *Service) SyncSupersession(record AdrData) ([]AdrData, error) {
+	var updated []AdrData
+	var errs []error
+	sync := func(ref string, complete func(other *AdrData, back string) bool) {
+		other, ok := s.lookupRef(ref)
+		if !ok || other.Archived {
+			return
+		}
+		owner, err := s.For(other)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("record %q: %w", ref, err))
+			return
+		}
+		if !complete(&other, owner.Ref(record)) {
+			return
+		}
+		if err := owner.UpdateRecord(other); err != nil {
+			errs = append(errs, fmt.Errorf("record %q: %w", other.ID, err))
+			return
+		}
+		other, _ = owner.GetRecord(other.ID)
+		updated = append(updated, other)
+	}
+	for _, ref := range record.Superseders.ToSlice() {
+		sync(ref, func(superseder *AdrData, back string) bool {
+			if superseder.Supersedes[back] {
+				return false
+			}
+			superseder.Supersedes = maps.Clone(superseder.Supersedes)
+			superseder.Supersedes.Append(back)
+			return true
+		})
+	}
+	for _, ref := range record.Supersedes.ToSlice() {
+		sync(ref, func(superseded *AdrData, back string) bool {
+			if superseded.Superseders[back] {
+				return false
+			}
+			superseded.Superseders = maps.Clone(superseded.Superseders)
+			superseded.Superseders.Append(back)
+			superseded.Status = SUPERSEDED
+			return true
+		})
+	}
+	return updated, errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package records
+
+import (
+	"testing"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+func TestSyncSupersession(t *testing.T) {
+	storage := NewMemStorage()
+	if err := storage.MkdirAll("adrs"); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	old, _ := svc.CreateRecord("Old", AdrData{ID: "old", Status: ACCEPTED}, "")
+	older, _ := svc.CreateRecord("Older", AdrData{ID: "older", Status: ACCEPTED}, "")
+	latest, _ := svc.CreateRecord("Latest", AdrData{ID: "latest", Status: ACCEPTED, Supersedes: Set[string]{"old": true, "ghost": true}}, "")
+
+	// From the superseder: the superseded record gains the superseder and status.
+	updated, err := svc.SyncSupersession(latest)
+	if err != nil {
+		t.Fatalf("SyncSupersession(latest): %v", err)
+	}
+	if len(updated) != 1 || updated[0].ID != "old" {
+		t.Errorf("updated = %+v, want old", updated)
+	}
+	old, _ = svc.GetRecord("old")
+	if !old.Superseders["latest"] || old.Status != SUPERSEDED {
+		t.Errorf("old = %v %v, want superseded by latest", old.Status, old.Superseders)
+	}
+
+	// From the superseded record: the superseder gains supersedes.
+	older.Status = SUPERSEDED
+	older.Superseders.Append("old")
+	if err := svc.UpdateRecord(older); err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	if _, err := svc.SyncSupersession(older); err != nil {
+		t.Fatalf("SyncSupersession(older): %v", err)
+	}
+	reopened, err := Open(storage, cs.Config{Directory: "adrs"})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	old, _ = reopened.GetRecord("old")
+	if !old.Supersedes["older"] || !old.Superseders["latest"] {
+		t.Errorf("old = supersedes %v, superseders %v, want older and latest", old.Supersedes, old.Superseders)
+	}
+
+	// Nothing left to complete.
+	if updated, err := reopened.SyncSupersession(old); err != nil || len(updated) != 0 {
+		t.Errorf("SyncSupersession(old) = %+v, %v, want nothing", updated, err)
+	}
+
+	// Deleting a superseded record removes it from its superseder.
+	older, _ = reopened.GetRecord("older")
+	if _, err := reopened.DeleteRecord(older, false); err != nil {
+		t.Fatalf("DeleteRecord: %v", err)
+	}
+	if old, _ = reopened.GetRecord("old"); len(old.Supersedes) != 0 {
+		t.Errorf("old supersedes %v after deleting older, want nothing", old.Supersedes)
+	}
+}
@@ -3,8 +3,9 @@ package records
 import (
 	"bytes"
 	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"slices"
 	"strings"
 	"time"
@@ -27,10 +28,11 @@ const (
 	OBSERVED   AdrStatus = "observed"
 )
 
-// AdrStatuses lists every allowed status in display order.
+// AdrStatuses lists the built-in statuses in display order. A project can
+// declare more in its configuration (see Workflow).
 var AdrStatuses = []AdrStatus{UNKNOWN, PROPOSED, ACCEPTED, DEPRECATED, SUPERSEDED, OBSERVED}
 
-// AdrStatusDescriptions documents the meaning of each status.
+// AdrStatusDescriptions documents the meaning of each built-in status.
 var AdrStatusDescriptions = map[AdrStatus]string{
 	UNKNOWN:    "status is not determined",
 	PROPOSED:   "the record has been proposed but is not accepted yet by stakeholders",
@@ -40,56 +42,11 @@ var AdrStatusDescriptions = map[AdrStatus]string{
 	OBSERVED:   "documents a pre-existing decision reconstructed after the fact, e.g. while making sense of legacy code you did not write",
 }
 
-// StatusHelp returns a multi-line block listing each status and its meaning,
-// suitable for appending to a command's help description.
-func StatusHelp() string {
-	var b strings.Builder
-	b.WriteString("Statuses:")
-	for _, s := range AdrStatuses {
-		fmt.Fprintf(&b, "\n  %-11s %s", s, AdrStatusDescriptions[s])
-	}
-	return b.String()
-}
-
 // String is used by fmt.Print and everywhere a status is rendered as text.
 func (e AdrStatus) String() string {
 	return string(e)
 }
 
-// ParseStatus validates a raw value and returns the matching AdrStatus.
-func ParseStatus(v string) (AdrStatus, error) {
-	status := AdrStatus(v)
-	if slices.Contains(AdrStatuses, status) {
-		return status, nil
-	}
-	return "", fmt.Errorf("must be one of %s", AllowedStatuses())
-}
-
-// AllowedStatuses returns the allowed statuses formatted for help/error messages,
-// e.g. `"unknown", "proposed", "accepted", "deprecated", "superseded" or "observed"`.
-func AllowedStatuses() string {
-	quoted := make([]string, len(AdrStatuses))
-	for i, s := range AdrStatuses {
-		quoted[i] = fmt.Sprintf("%q", s)
-	}
-	if len(quoted) < 2 {
-		return strings.Join(quoted, "")
-	}
-	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
-}
-
-// Colorized returns the AdrStatus as a colored string
-func (e AdrStatus) Colorized() string {
-	switch e {
-	case PROPOSED:
-		return cs.Yellow(e.String())
-	case ACCEPTED, OBSERVED:
-		return cs.Green(e.String())
-	default:
-		return cs.Grey(e.String())
-	}
-}
-
 type AdrData struct {
 	ID             string      `yaml:"id" json:"id"`
 	Title          string      `yaml:"title" json:"title"`
@@ -99,16 +56,71 @@ type AdrData struct {
 	LastUpdateDate time.Time   `yaml:"last_update_date" mapstructure:"last_update_date" json:"last_update_date"`
 	Tags           Set[string] `yaml:"tags,omitempty" json:"tags,omitempty"`
 	Superseders    Set[string] `yaml:"superseders,omitempty" json:"superseders,omitempty"`
-
-	Name string `yaml:"-" json:"file"`
-	Body string `yaml:"-" json:"-"`
-}
-
-func (a AdrData) ToRow() []string {
+	// Supersedes lists the records this one supersedes: the inverse of their
+	// Superseders.
+	Supersedes Set[string] `yaml:"supersedes,omitempty" json:"supersedes,omitempty"`
+	// Links lists the records this one is linked to, by relation name (see
+	// Relations).
+	Links map[string]Set[string] `yaml:"links,omitempty" mapstructure:"-" json:"links,omitempty"`
+	// History lists the status changes of the record, oldest first.
+	History []HistoryEntry `yaml:"history,omitempty" mapstructure:"-" json:"history,omitempty"`
+
+	// Extra holds the front-matter keys not modeled above, preserved on rewrite.
+	Extra Extras `yaml:"-" mapstructure:"-" json:"extra,omitzero"`
+
+	// Name is the path of the record file, relative to the ADR directory.
+	Name string `yaml:"-" mapstructure:"-" json:"file"`
+	Body string `yaml:"-" mapstructure:"-" json:"-"`
+	// Collection is the name of the collection the record belongs to ("" when the
+	// project has a single ADR log).
+	Collection string `yaml:"-" mapstructure:"-" json:"collection,omitempty"`
+	// Project is the directory of the configuration file the record was found
+	// through, relative to where the projects were discovered ("" otherwise).
+	Project string `yaml:"-" mapstructure:"-" json:"project,omitempty"`
+	// Category is the subdirectory the record is in ("" at the top level).
+	Category string `yaml:"-" mapstructure:"-" json:"category,omitempty"`
+	// Draft is set on records of the drafts directory, which have no number yet.
+	Draft bool `yaml:"-" mapstructure:"-" json:"draft,omitempty"`
+	// Archived is set on records of the archive directory, which are read-only.
+	Archived bool `yaml:"-" mapstructure:"-" json:"archived,omitempty"`
+
+	// checksum identifies the file content the record was read from, so updates
+	// can detect edits made on disk in the meantime ("" when unknown).
+	checksum string
+	// partial is set on records indexed without their body, whose checksum then
+	// only covers the front-matter.
+	partial bool
+	// references are the records the body refers to (see referenceTargets),
+	// kept in the index cache even for records indexed without their body.
+	// referencesKnown tells whether they were read: a record indexed from its
+	// front-matter only does not know them until its body is read once.
+	references      []string
+	referencesKnown bool
+}
+
+// HistoryEntry records a status change: the new status, when and by whom it was
+// set, and optionally why.
+type HistoryEntry struct {
+	Status AdrStatus `yaml:"status" json:"status"`
+	Date   time.Time `yaml:"date" json:"date"`
+	Actor  string    `yaml:"actor,omitempty" json:"actor,omitempty"`
+	Reason string    `yaml:"reason,omitempty" json:"reason,omitempty"`
+}
+
+// ToRow renders a record as a row of the records table, its status colored as
+// configured in the workflow of its collection.
+func (a AdrData) ToRow(workflow Workflow) []string {
+	title := a.Title
+	switch {
+	case a.Draft:
+		title += cs.Grey(" (draft)")
+	case a.Archived:
+		title += cs.Grey(" (archived)")
+	}
 	return []string{
 		a.ID,
-		a.Title,
-		a.Status.Colorized(),
+		title,
+		workflow.Colorize(a.Status),
 		a.Author,
 		humanizeTime(a.CreationDate),
 		humanizeTime(a.LastUpdateDate),
@@ -173,6 +185,12 @@ func (s *Set[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// checksum returns a hex-encoded SHA-256 digest of a record file's content.
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
 func MarshalYAML(v any) ([]byte, error) {
 	var b bytes.Buffer
 	yamlEncoder := yaml.NewEncoder(&b)
@@ -0,0 +1,185 @@
+package records
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cs "github.com/gwleclerc/adr/constants"
+)
+
+// statusColors maps the color names accepted in the configuration to printers.
+var statusColors = map[string]func(string, ...any) string{
+	"red":     cs.Red,
+	"green":   cs.Green,
+	"yellow":  cs.Yellow,
+	"grey":    cs.Grey,
+	"blue":    cs.Blue,
+	"magenta": cs.Magenta,
+	"cyan":    cs.Cyan,
+}
+
+// Workflow is the set of statuses a project uses, how they are rendered, and
+// which status changes are allowed.
+type Workflow struct {
+	statuses     []AdrStatus
+	descriptions map[AdrStatus]string
+	colors       map[AdrStatus]string
+	// transitions lists the statuses reachable from each status; nil means any
+	// status change is allowed.
+	transitions map[AdrStatus][]AdrStatus
+}
+
+// DefaultWorkflow returns the built-in statuses, with no transition rules.
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		statuses:     slices.Clone(AdrStatuses),
+		descriptions: AdrStatusDescriptions,
+		colors: map[AdrStatus]string{
+			PROPOSED: "yellow",
+			ACCEPTED: "green",
+			OBSERVED: "green",
+		},
+	}
+}
+
+// NewWorkflow builds the workflow declared in the configuration. Declared
+// statuses are added to the built-in ones (a declared built-in name overrides its
+// description and color). When transitions are declared, a status can only move
+// to the statuses listed for it, and a status without an entry is final.
+func NewWorkflow(statuses []cs.StatusSpec, transitions map[string][]string) (Workflow, error) {
+	w := DefaultWorkflow()
+	w.descriptions = make(map[AdrStatus]string, len(AdrStatusDescriptions)+len(statuses))
+	for k, v := range AdrStatusDescriptions {
+		w.descriptions[k] = v
+	}
+	for _, spec := range statuses {
+		status := AdrStatus(spec.Name)
+		if spec.Name == "" || strings.TrimSpace(spec.Name) != spec.Name {
+			return Workflow{}, fmt.Errorf("invalid status name %q", spec.Name)
+		}
+		if spec.Color != "" {
+			if _, ok := statusColors[spec.Color]; !ok {
+				return Workflow{}, fmt.Errorf("status %q has invalid color %q: must be one of %s", spec.Name, spec.Color, strings.Join(colorNames(), ", "))
+			}
+			w.colors[status] = spec.Color
+		}
+		if spec.Description != "" {
+			w.descriptions[status] = spec.Description
+		}
+		if !slices.Contains(w.statuses, status) {
+			w.statuses = append(w.statuses, status)
+		}
+	}
+
+	if len(transitions) == 0 {
+		return w, nil
+	}
+	w.transitions = make(map[AdrStatus][]AdrStatus, len(transitions))
+	for from, tos := range transitions {
+		if !w.Declared(AdrStatus(from)) {
+			return Workflow{}, fmt.Errorf("transition from undeclared status %q", from)
+		}
+		for _, to := range tos {
+			if !w.Declared(AdrStatus(to)) {
+				return Workflow{}, fmt.Errorf("transition from %q to undeclared status %q", from, to)
+			}
+			w.transitions[AdrStatus(from)] = append(w.transitions[AdrStatus(from)], AdrStatus(to))
+		}
+	}
+	return w, nil
+}
+
+// Statuses returns the statuses in display order.
+func (w Workflow) Statuses() []AdrStatus {
+	return w.statuses
+}
+
+// Declared reports whether a status belongs to the workflow.
+func (w Workflow) Declared(status AdrStatus) bool {
+	return slices.Contains(w.statuses, status)
+}
+
+// Description returns the meaning of a status.
+func (w Workflow) Description(status AdrStatus) string {
+	return w.descriptions[status]
+}
+
+// CanTransition reports whether a record may move from one status to another.
+// Keeping the same status is always allowed.
+func (w Workflow) CanTransition(from, to AdrStatus) bool {
+	if w.transitions == nil || from == to {
+		return true
+	}
+	return slices.Contains(w.transitions[from], to)
+}
+
+// Next returns the statuses a record may move to from a status (nil when
+// transitions are unrestricted).
+func (w Workflow) Next(from AdrStatus) []AdrStatus {
+	if w.transitions == nil {
+		return nil
+	}
+	return w.transitions[from]
+}
+
+// Color returns the name of the color a status is rendered with.
+func (w Workflow) Color(status AdrStatus) string {
+	if _, ok := statusColors[w.colors[status]]; ok {
+		return w.colors[status]
+	}
+	return "grey"
+}
+
+// Colorize returns a status as a string colored as configured.
+func (w Workflow) Colorize(status AdrStatus) string {
+	return statusColors[w.Color(status)](status.String())
+}
+
+// ParseStatus validates a raw value against the statuses of the workflow and
+// returns the matching AdrStatus.
+func (w Workflow) ParseStatus(v string) (AdrStatus, error) {
+	status := AdrStatus(v)
+	if w.Declared(status) {
+		return status, nil
+	}
+	return "", fmt.Errorf("must be one of %s", w.AllowedStatuses())
+}
+
+// AllowedStatuses returns the statuses of the workflow formatted for help/error
+// messages, e.g. `"unknown", "proposed", "accepted", "deprecated", "superseded"
+// or "observed"`.
+func (w Workflow) AllowedStatuses() string {
+	return quoteStatuses(w.statuses)
+}
+
+func quoteStatuses(statuses []AdrStatus) string {
+	quoted := make([]string, len(statuses))
+	for i, s := range statuses {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// StatusHelp returns a multi-line block listing each status of the workflow and
+// its meaning, suitable for appending to a command's help description.
+func (w Workflow) StatusHelp() string {
+	var b strings.Builder
+	b.WriteString("Statuses:")
+	for _, s := range w.statuses {
+		fmt.Fprintf(&b, "\n  %-11s %s", s, w.Description(s))
+	}
+	return b.String()
+}
+
+func colorNames() []string {
+	names := make([]string, 0, len(statusColors))
+	for name := range statusColors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
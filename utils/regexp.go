@@ -2,7 +2,9 @@ package utils
 
 import "regexp"
 
-var recordNamePatternRegex = regexp.MustCompile(`(\d+)_\S+`)
+// recordNamePatternRegex matches a numbered record filename ("NNN_title.md"),
+// possibly preceded by a directory.
+var recordNamePatternRegex = regexp.MustCompile(`(?:^|/)(\d+)_\S+`)
 
 func GetRecordNumber(name string) string {
 	match := recordNamePatternRegex.FindStringSubmatch(name)
@@ -13,6 +13,8 @@ func TestGetRecordNumber(t *testing.T) {
 		{"no separator", "123.md", ""},
 		{"no number", "title.md", ""},
 		{"empty", "", ""},
+		{"in a directory", "drafts/042_title.md", "042"},
+		{"digits inside the title", "drafts/use_http2_now.md", ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {